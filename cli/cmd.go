@@ -2,6 +2,7 @@ package cli
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"strings"
 
@@ -13,28 +14,108 @@ import (
 type cmdJsonItem struct {
 	Value    string        `json:"value"`
 	Label    string        `json:"label"`
-	Children []cmdJsonItem `json:"children"`
+	Children []cmdJsonItem `json:"children,omitempty"`
+
+	// 作为children中的步骤时的配置
+	Id              string `json:"id,omitempty"`
+	ContinueOnError bool   `json:"continue_on_error,omitempty"`
+	Retry           int    `json:"retry,omitempty"`
+	RetryDelayMs    int    `json:"retry_delay_ms,omitempty"`
+	TimeoutMs       int    `json:"timeout_ms,omitempty"`
+	When            string `json:"when,omitempty"`
+
+	// children同时执行，fail_fast为true时一项失败后取消其他的
+	Parallel bool `json:"parallel,omitempty"`
+	FailFast bool `json:"fail_fast,omitempty"`
 }
 
 type cmdJsonType struct {
 	Commands []cmdJsonItem `json:"commands"`
 }
 
+// 同时执行的命令数量
+var cmdParallel int
+
+// 不交互执行时使用的参数值，dora cmd run --set name=值
+var cmdRunParams map[string]string
+
+// 不交互执行，参数只从--set和默认值中读取
+var cmdHeadless bool
+
+// 以json输出结果，命令的输出改为写到stderr
+var cmdJsonOutput bool
+
+// 读取配置中的所有命令
+func readCommands() ([]cmdJsonItem, error) {
+	var jsonData cmdJsonType
+	if err := tools.ReadDoraJsonConfig(&jsonData); err != nil {
+		return nil, err
+	}
+	return jsonData.Commands, nil
+}
+
+// 替换命令中的 {{参数}}，不交互时缺少参数直接报错
+func expandCommand(command string) (string, error) {
+	if !cmdHeadless {
+		return expandTemplate(command)
+	}
+	command, missing := tools.FillTemplate(command, cmdRunParams)
+	if len(missing) > 0 {
+		return "", fmt.Errorf("缺少参数 %s，使用 --set %s=值 指定", strings.Join(missing, "、"), missing[0])
+	}
+	return command, nil
+}
+
+// 命令输出的位置，json输出时stdout只输出结果
+func commandStdout() io.Writer {
+	if cmdJsonOutput {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+// 执行一条命令，有children时按步骤执行，parallel为true时children同时执行
+func runCmdItem(item cmdJsonItem) ([]*stepResult, error) {
+	w := newWorkflow()
+	if len(item.Children) > 0 {
+		if item.Parallel {
+			return runParallel(w, item.Children, cmdParallel, item.FailFast)
+		}
+		err := w.Run(item.Children)
+		return w.results, err
+	}
+
+	result := &stepResult{name: stepName(item), status: stepFailed, exitCode: -1}
+	// 命令中有 {{参数}} 时，先询问参数的值
+	command, err := expandCommand(item.Value)
+	if err != nil {
+		return []*stepResult{result}, err
+	}
+	item.Value = command
+	w.expanded = true
+	w.runStep(item, result)
+	if result.status != stepSuccess {
+		return []*stepResult{result}, fmt.Errorf("退出码 %d", result.exitCode)
+	}
+	return []*stepResult{result}, nil
+}
+
 var cmdTip = &cobra.Command{
 	Use:   "cmd",
-	Short: "列举dora配置文件中的所有命令，可筛选多选命令依次执行",
+	Short: "列举dora配置文件中的所有命令，可筛选多选命令依次执行，--parallel 时并行执行",
 	Run: func(cobraCmd *cobra.Command, args []string) {
+		tools.RunSource = "cmd"
 		// jsonFilePath := "./configs/cmd.json"
 		// userHomeDir, _ := os.UserHomeDir()
 		// jsonFilePath := filepath.Join(userHomeDir, "dora/.config.json")
-		var jsonData cmdJsonType
-		if err := tools.ReadDoraJsonConfig(&jsonData); err != nil {
+		commands, err := readCommands()
+		if err != nil {
 			fmt.Println("ReadJsonError", err)
 			os.Exit(1)
 		}
 
 		// 类型转化
-		searchParams := tools.Convert(jsonData.Commands, func(cmdItem cmdJsonItem) cmd.CommandItem {
+		searchParams := tools.Convert(commands, func(cmdItem cmdJsonItem) cmd.CommandItem {
 			childCmds := []string{}
 			if len(cmdItem.Children) > 0 {
 				for _, item := range cmdItem.Children {
@@ -52,30 +133,30 @@ var cmdTip = &cobra.Command{
 		if err != nil {
 			fmt.Println("cmd Search error", err)
 		}
+		selected := []cmdJsonItem{}
 		for _, v := range result {
-			waitRunCmds := []string{}
-			// 找到匹配的命令，假如有children属性，执行children里面的内容
-			for _, item := range jsonData.Commands {
+			for _, item := range commands {
 				if item.Value == v {
-					if len(item.Children) > 0 {
-						for _, child := range item.Children {
-							waitRunCmds = append(waitRunCmds, child.Value)
-						}
-					} else {
-						waitRunCmds = append(waitRunCmds, v)
-					}
+					selected = append(selected, item)
 				}
 			}
-			for _, cmdItem := range waitRunCmds {
-				err := tools.RunCommandWithLog(cmdItem)
-				if err != nil {
-					fmt.Println("执行失败", v, err)
-				}
+		}
+		// 指定了--parallel时，选择的多条命令同时执行
+		if cmdParallel > 1 && len(selected) > 1 {
+			if _, err := runParallel(newWorkflow(), selected, cmdParallel, false); err != nil {
+				fmt.Println(err)
+			}
+			return
+		}
+		for _, item := range selected {
+			if _, err := runCmdItem(item); err != nil {
+				fmt.Println("执行失败", item.Value, err)
 			}
 		}
 	},
 }
 
 func init() {
+	cmdTip.PersistentFlags().IntVarP(&cmdParallel, "parallel", "P", 0, "同时执行的命令数量，选择多条命令时并行执行，也限制parallel分组的并发数")
 	rootCmd.AddCommand(cmdTip)
 }
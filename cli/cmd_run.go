@@ -0,0 +1,247 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/haokur/dora/tools"
+	"github.com/spf13/cobra"
+)
+
+// 找不到命令时的退出码
+const cmdNotFoundExitCode = 2
+
+var cmdDryRun bool
+
+// json输出中的步骤状态
+var stepStatusCodes = map[string]string{
+	stepSuccess:  "success",
+	stepFailed:   "failed",
+	stepTimeout:  "timeout",
+	stepSkipped:  "skipped",
+	stepCanceled: "canceled",
+}
+
+// dora cmd run --json 的输出
+type cmdRunJson struct {
+	Name       string            `json:"name"`
+	Value      string            `json:"value"`
+	Status     string            `json:"status"`
+	ExitCode   int               `json:"exit_code"`
+	DurationMs int64             `json:"duration_ms"`
+	Error      string            `json:"error,omitempty"`
+	Steps      []cmdRunStepJson  `json:"steps"`
+	Params     map[string]string `json:"params,omitempty"`
+}
+
+type cmdRunStepJson struct {
+	Name       string `json:"name"`
+	Status     string `json:"status"`
+	ExitCode   int    `json:"exit_code"`
+	DurationMs int64  `json:"duration_ms"`
+	Attempts   int    `json:"attempts"`
+	Output     string `json:"output,omitempty"`
+}
+
+// dora cmd run --dry-run 的输出
+type cmdPlanJson struct {
+	Name            string        `json:"name"`
+	Command         string        `json:"command,omitempty"`
+	MissingParams   []string      `json:"missing_params,omitempty"`
+	When            string        `json:"when,omitempty"`
+	Retry           int           `json:"retry,omitempty"`
+	TimeoutMs       int           `json:"timeout_ms,omitempty"`
+	ContinueOnError bool          `json:"continue_on_error,omitempty"`
+	Parallel        bool          `json:"parallel,omitempty"`
+	Steps           []cmdPlanJson `json:"steps,omitempty"`
+}
+
+// 按value查找命令，找不到时按label查找
+func findCommand(commands []cmdJsonItem, name string) (cmdJsonItem, error) {
+	for _, item := range commands {
+		if item.Value == name {
+			return item, nil
+		}
+	}
+	matches := []cmdJsonItem{}
+	for _, item := range commands {
+		if item.Label == name {
+			matches = append(matches, item)
+		}
+	}
+	if len(matches) == 1 {
+		return matches[0], nil
+	}
+	if len(matches) > 1 {
+		return cmdJsonItem{}, fmt.Errorf("有 %d 条命令的label为 %s，请使用命令的value", len(matches), name)
+	}
+	return cmdJsonItem{}, fmt.Errorf("未找到命令 %s，可执行 dora cmd list 查看", name)
+}
+
+// 生成执行计划，替换已知的参数，不执行命令
+func planCommand(item cmdJsonItem) cmdPlanJson {
+	plan := cmdPlanJson{
+		Name:            stepName(item),
+		When:            item.When,
+		Retry:           item.Retry,
+		TimeoutMs:       item.TimeoutMs,
+		ContinueOnError: item.ContinueOnError,
+		Parallel:        item.Parallel,
+	}
+	if len(item.Children) == 0 {
+		plan.Command, plan.MissingParams = tools.FillTemplate(item.Value, cmdRunParams)
+		return plan
+	}
+	for _, child := range item.Children {
+		plan.Steps = append(plan.Steps, planCommand(child))
+	}
+	return plan
+}
+
+func printPlan(plan cmdPlanJson, indent string) {
+	options := []string{}
+	if plan.Parallel {
+		options = append(options, "并行")
+	}
+	if plan.When != "" {
+		options = append(options, "when: "+plan.When)
+	}
+	if plan.Retry > 0 {
+		options = append(options, fmt.Sprintf("重试 %d 次", plan.Retry))
+	}
+	if plan.TimeoutMs > 0 {
+		options = append(options, fmt.Sprintf("超时 %dms", plan.TimeoutMs))
+	}
+	if plan.ContinueOnError {
+		options = append(options, "失败后继续")
+	}
+	line := indent + plan.Name
+	if plan.Command != "" && plan.Command != plan.Name {
+		line += ": " + plan.Command
+	}
+	if len(options) > 0 {
+		line += "（" + strings.Join(options, "，") + "）"
+	}
+	if len(plan.MissingParams) > 0 {
+		line += "  缺少参数: " + strings.Join(plan.MissingParams, "、")
+	}
+	fmt.Println(line)
+	for _, step := range plan.Steps {
+		printPlan(step, indent+"  ")
+	}
+}
+
+func printJson(value interface{}) {
+	data, _ := json.MarshalIndent(value, "", "  ")
+	fmt.Println(string(data))
+}
+
+// 失败时的退出码，单条命令使用命令的退出码，否则使用第一个失败步骤的退出码
+func runExitCode(results []*stepResult) int {
+	for _, result := range results {
+		if result.status == stepSuccess || result.status == stepSkipped {
+			continue
+		}
+		if result.exitCode > 0 {
+			return result.exitCode
+		}
+		return 1
+	}
+	return 1
+}
+
+var cmdRunCmd = &cobra.Command{
+	Use:   "run <value或label>",
+	Short: "不交互执行配置中的一条命令，可用于脚本和CI",
+	Long:  "不交互执行配置中的一条命令，有children时按步骤执行，返回命令的退出码\n参数使用 --set 指定，未指定的使用默认值\n例如：dora cmd run 部署 --set env=prod --json",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cobraCmd *cobra.Command, args []string) {
+		cmdHeadless = true
+		tools.RunSource = "cmd run"
+		commands, err := readCommands()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		item, err := findCommand(commands, args[0])
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(cmdNotFoundExitCode)
+		}
+
+		if cmdDryRun {
+			plan := planCommand(item)
+			if cmdJsonOutput {
+				printJson(plan)
+			} else {
+				printPlan(plan, "")
+			}
+			return
+		}
+
+		results, err := runCmdItem(item)
+		if cmdJsonOutput {
+			output := cmdRunJson{Name: stepName(item), Value: item.Value, Status: "success", Steps: []cmdRunStepJson{}, Params: cmdRunParams}
+			for _, result := range results {
+				output.DurationMs += result.duration.Milliseconds()
+				output.Steps = append(output.Steps, cmdRunStepJson{
+					Name:       result.name,
+					Status:     stepStatusCodes[result.status],
+					ExitCode:   result.exitCode,
+					DurationMs: result.duration.Milliseconds(),
+					Attempts:   result.attempts,
+					Output:     tools.MaskSecrets(result.output, result.secrets),
+				})
+			}
+			if err != nil {
+				output.Status = "failed"
+				output.ExitCode = runExitCode(results)
+				output.Error = err.Error()
+			}
+			printJson(output)
+		} else if err != nil {
+			fmt.Fprintln(os.Stderr, "执行失败", item.Value, err)
+		}
+		if err != nil {
+			os.Exit(runExitCode(results))
+		}
+	},
+}
+
+var cmdListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "列出配置中的所有命令",
+	Run: func(cobraCmd *cobra.Command, args []string) {
+		commands, err := readCommands()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		if cmdJsonOutput {
+			printJson(commands)
+			return
+		}
+		for _, item := range commands {
+			line := item.Value
+			if item.Label != "" {
+				line += "（" + item.Label + "）"
+			}
+			if len(item.Children) > 0 {
+				line += fmt.Sprintf("  %d 个步骤", len(item.Children))
+			}
+			fmt.Println(line)
+		}
+	},
+}
+
+func init() {
+	cmdRunCmd.Flags().BoolVar(&cmdDryRun, "dry-run", false, "只输出将要执行的命令，不执行")
+	cmdRunCmd.Flags().StringToStringVar(&cmdRunParams, "set", map[string]string{}, "命令中 {{参数}} 的值，如 --set branch=main")
+	for _, c := range []*cobra.Command{cmdRunCmd, cmdListCmd} {
+		c.Flags().BoolVar(&cmdJsonOutput, "json", false, "以json输出，命令自身的输出写到stderr")
+	}
+	cmdTip.AddCommand(cmdRunCmd)
+	cmdTip.AddCommand(cmdListCmd)
+}
@@ -0,0 +1,161 @@
+package cli
+
+import (
+	"strings"
+
+	"github.com/c-bata/go-prompt"
+	"github.com/haokur/dora/tools"
+)
+
+// 根据已输入的命令动态计算的提示，如路径、git分支、npm scripts
+type dynamicCompleter struct {
+	name string
+	// args为正在输入的参数之前的命令，不包含以-开头的选项
+	match func(args []string) bool
+	// 计算候选项，dir为当前目录，word为正在输入的参数
+	load func(dir string, word string) []tools.Completion
+	// 候选项与word有关时，返回缓存使用的范围，如路径所在的目录
+	scope func(word string) string
+}
+
+// 在这里添加新的动态提示，按顺序使用第一个匹配的
+var dynamicCompleters = []dynamicCompleter{
+	{
+		name:  "dir",
+		match: commandIs("cd", "pushd"),
+		load: func(dir string, word string) []tools.Completion {
+			return tools.PathCompletions(dir, word, true)
+		},
+		scope: pathScope,
+	},
+	{
+		name:  "path",
+		match: commandIs("cat", "less", "more", "head", "tail", "vi", "vim", "nvim", "code", "open", "ls", "rm", "cp", "mv", "source", "bat"),
+		load: func(dir string, word string) []tools.Completion {
+			return tools.PathCompletions(dir, word, false)
+		},
+		scope: pathScope,
+	},
+	{
+		name:  "git-remote",
+		match: argsAre([]string{"git", "push"}, []string{"git", "pull"}, []string{"git", "fetch"}),
+		load: func(dir string, word string) []tools.Completion {
+			return tools.GitRemoteCompletions(dir)
+		},
+	},
+	{
+		name: "git-branch",
+		match: func(args []string) bool {
+			if argsAre([]string{"git", "checkout"}, []string{"git", "switch"}, []string{"git", "merge"}, []string{"git", "rebase"}, []string{"git", "diff"}, []string{"git", "log"})(args) {
+				return true
+			}
+			// git push origin 之后提示分支
+			return len(args) == 3 && args[0] == "git" && (args[1] == "push" || args[1] == "pull")
+		},
+		load: func(dir string, word string) []tools.Completion {
+			return tools.GitBranchCompletions(dir)
+		},
+	},
+	{
+		name:  "npm-script",
+		match: argsAre([]string{"npm", "run"}, []string{"yarn", "run"}, []string{"pnpm", "run"}, []string{"yarn"}, []string{"pnpm"}),
+		load: func(dir string, word string) []tools.Completion {
+			return tools.NpmScriptCompletions(dir)
+		},
+	},
+	{
+		name:  "make-target",
+		match: commandIs("make"),
+		load: func(dir string, word string) []tools.Completion {
+			return tools.MakeTargetCompletions(dir)
+		},
+	},
+	{
+		name:  "docker-container",
+		match: argsAre([]string{"docker", "exec"}, []string{"docker", "logs"}, []string{"docker", "start"}, []string{"docker", "stop"}, []string{"docker", "restart"}, []string{"docker", "rm"}, []string{"docker", "attach"}, []string{"docker", "inspect"}),
+		load: func(dir string, word string) []tools.Completion {
+			return tools.DockerContainerCompletions()
+		},
+	},
+}
+
+// 第一个参数为其中之一的命令，之后的每个参数都提示
+func commandIs(commands ...string) func(args []string) bool {
+	return func(args []string) bool {
+		return len(args) > 0 && tools.SliceContains(commands, args[0])
+	}
+}
+
+// 参数正好是其中之一，如 git checkout
+func argsAre(candidates ...[]string) func(args []string) bool {
+	return func(args []string) bool {
+		for _, candidate := range candidates {
+			if strings.Join(args, " ") == strings.Join(candidate, " ") {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// 路径按所在的目录和是否显示隐藏文件缓存
+func pathScope(word string) string {
+	prefix := ""
+	if i := strings.LastIndex(word, "/"); i != -1 {
+		prefix = word[:i+1]
+	}
+	if strings.HasPrefix(word[len(prefix):], ".") {
+		return prefix + "."
+	}
+	return prefix
+}
+
+// 动态提示的缓存，执行命令后清空，命令可能新建了文件、切换了分支
+var dynamicCache = map[string][]tools.Completion{}
+
+func clearDynamicCache() {
+	dynamicCache = map[string][]tools.Completion{}
+}
+
+// 根据光标前的内容计算动态提示，没有匹配的动态提示时返回nil
+func getDynamicSuggestions(t prompt.Document) []prompt.Suggest {
+	text := strings.TrimLeft(t.TextBeforeCursor(), " ")
+	if !strings.Contains(text, " ") {
+		return nil
+	}
+	word := t.GetWordBeforeCursor()
+	args := []string{}
+	for _, arg := range strings.Fields(strings.TrimSuffix(text, word)) {
+		if !strings.HasPrefix(arg, "-") {
+			args = append(args, arg)
+		}
+	}
+
+	for _, completer := range dynamicCompleters {
+		if !completer.match(args) {
+			continue
+		}
+		dir := tools.GetWorkDir()
+		key := completer.name + "\x00" + dir
+		if completer.scope != nil {
+			key += "\x00" + completer.scope(word)
+		}
+		completions, ok := dynamicCache[key]
+		if !ok {
+			completions = completer.load(dir, word)
+			dynamicCache[key] = completions
+		}
+		if word != "" {
+			completions = tools.RankMatches(completions, "Text", word, nil)
+		}
+		if len(completions) > maxSuggestions {
+			completions = completions[:maxSuggestions]
+		}
+		suggestions := make([]prompt.Suggest, 0, len(completions))
+		for _, completion := range completions {
+			suggestions = append(suggestions, prompt.Suggest{Text: completion.Text, Description: completion.Desc})
+		}
+		return suggestions
+	}
+	return nil
+}
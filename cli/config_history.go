@@ -0,0 +1,169 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/haokur/dora/cmd"
+	"github.com/haokur/dora/tools"
+	"github.com/spf13/cobra"
+)
+
+var rollbackYes bool
+
+// 快照的展示名称，如 2024-01-02 15:04:05 pull
+func describeSnapshot(name string) string {
+	format := tools.ConfigHistoryDateFormat
+	if len(name) < len(format) {
+		return name
+	}
+	date, err := time.ParseInLocation(format, name[:len(format)], time.Local)
+	if err != nil {
+		return name
+	}
+	reason := strings.TrimSuffix(strings.TrimPrefix(name[len(format):], "_"), filepath.Ext(name))
+	return fmt.Sprintf("%s %s", date.Format("2006-01-02 15:04:05"), reason)
+}
+
+func readSnapshot(name string) string {
+	content, _ := os.ReadFile(filepath.Join(tools.GetConfigHistoryDir(), name))
+	return string(content)
+}
+
+// 统计差异中新增和删除的行数
+func countDiff(diff []string) (int, int) {
+	added, removed := 0, 0
+	for _, line := range diff {
+		if strings.HasPrefix(line, "+") {
+			added++
+		} else {
+			removed++
+		}
+	}
+	return added, removed
+}
+
+var configHistoryCmd = &cobra.Command{
+	Use:   "history [快照]",
+	Short: "查看配置的历史快照，指定快照时显示和上一个快照的差异",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cobraCmd *cobra.Command, args []string) {
+		snapshots, err := tools.ListConfigSnapshots()
+		if err != nil {
+			fmt.Println("读取配置快照失败:", err)
+			os.Exit(1)
+		}
+		if len(snapshots) == 0 {
+			fmt.Println("暂无配置快照")
+			return
+		}
+
+		if len(args) == 1 {
+			index := -1
+			for i, name := range snapshots {
+				if name == args[0] {
+					index = i
+				}
+			}
+			if index == -1 {
+				fmt.Println("快照不存在:", args[0])
+				os.Exit(1)
+			}
+			previous := ""
+			if index+1 < len(snapshots) {
+				previous = readSnapshot(snapshots[index+1])
+			}
+			fmt.Println(describeSnapshot(snapshots[index]))
+			for _, line := range tools.DiffLines(previous, readSnapshot(snapshots[index])) {
+				fmt.Println(line)
+			}
+			return
+		}
+
+		for i, name := range snapshots {
+			previous := ""
+			if i+1 < len(snapshots) {
+				previous = readSnapshot(snapshots[i+1])
+			}
+			added, removed := countDiff(tools.DiffLines(previous, readSnapshot(name)))
+			fmt.Printf("%s  %s  +%d -%d\n", name, describeSnapshot(name), added, removed)
+		}
+	},
+}
+
+var configRollbackCmd = &cobra.Command{
+	Use:   "rollback [快照]",
+	Short: "将配置回滚到某个历史快照，未指定时交互选择",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cobraCmd *cobra.Command, args []string) {
+		snapshots, err := tools.ListConfigSnapshots()
+		if err != nil {
+			fmt.Println("读取配置快照失败:", err)
+			os.Exit(1)
+		}
+		if len(snapshots) == 0 {
+			fmt.Println("暂无配置快照")
+			return
+		}
+
+		name := ""
+		if len(args) == 1 {
+			name = args[0]
+		} else {
+			name, err = cmd.Radio("请选择要回滚的快照", &snapshots)
+			if err != nil {
+				fmt.Println("选择快照出错:", err)
+				return
+			}
+		}
+		if name == "" {
+			return
+		}
+		if !tools.SliceContains(snapshots, name) {
+			fmt.Println("快照不存在:", name)
+			os.Exit(1)
+		}
+
+		// 展示当前配置到快照的差异，确认后回滚
+		configPath := tools.GetDoraConfigPath()
+		current, _ := os.ReadFile(configPath)
+		content := readSnapshot(name)
+		diff := tools.DiffLines(string(current), content)
+		if len(diff) == 0 {
+			fmt.Println("当前配置和快照相同，无需回滚")
+			return
+		}
+		for _, line := range diff {
+			fmt.Println(line)
+		}
+		if !rollbackYes {
+			confirmed, err := cmd.Confirm(fmt.Sprintf("确认回滚到 %s", describeSnapshot(name)), true)
+			if err != nil || !confirmed {
+				return
+			}
+		}
+
+		// 快照的格式可能和当前配置不同，写入对应格式的文件并移除旧的
+		newConfigPath := filepath.Join(filepath.Dir(configPath), ".config"+filepath.Ext(name))
+		if newConfigPath != configPath {
+			tools.SaveConfigSnapshot(configPath, "backup")
+		}
+		if err := tools.WriteConfigWithHistory(newConfigPath, []byte(content), "rollback"); err != nil {
+			fmt.Println("写入配置文件失败:", err)
+			os.Exit(1)
+		}
+		if newConfigPath != configPath {
+			os.Remove(configPath)
+		}
+		fmt.Println("已回滚到快照:", name)
+	},
+}
+
+func init() {
+	configRollbackCmd.Flags().BoolVarP(&rollbackYes, "yes", "y", false, "不再确认，直接回滚")
+	configCmd.AddCommand(configHistoryCmd)
+	configCmd.AddCommand(configRollbackCmd)
+}
@@ -0,0 +1,135 @@
+package cli
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/haokur/dora/tools"
+)
+
+// 字符串列表
+func stringListSchema(description string, minItems int, format string) *tools.Schema {
+	return &tools.Schema{
+		Description: description,
+		Type:        "array",
+		MinItems:    minItems,
+		Items:       &tools.Schema{Type: "string", Format: format},
+	}
+}
+
+// 用户目录下的dora/.config.json
+var globalConfigSchema = &tools.Schema{
+	Title:       "dora global config",
+	Description: "用户目录下的dora/.config.json",
+	Type:        "object",
+	Properties: map[string]*tools.Schema{
+		"api_key": {Type: "string", Description: "同步配置使用的api_key，推送时不会上传"},
+		"name":    {Type: "string", Description: "配置名称"},
+		"sync": {
+			Type:        "object",
+			Description: "dora config pull/push 的同步方式",
+			Properties: map[string]*tools.Schema{
+				"backend": {Type: "string", Enum: []string{tools.SyncBackendHTTP, tools.SyncBackendGit, tools.SyncBackendDir}},
+				"url":     {Type: "string", Description: "http：配置文件的https地址"},
+				"token":   {Type: "string", Description: "http：Bearer认证的token，默认使用api_key，推送时不会上传"},
+				"repo":    {Type: "string", Description: "git：仓库地址"},
+				"branch":  {Type: "string", Description: "git：分支，默认main"},
+				"path":    {Type: "string", Description: "git：仓库中的文件路径；dir：共享目录或文件路径"},
+			},
+		},
+		"commands":      {Type: "array", Description: "dora cmd 可选择执行的命令", Items: &tools.Schema{Ref: "command"}},
+		"prompts":       {Type: "array", Description: "dora 交互命令行的提示", Items: &tools.Schema{Ref: "prompt"}},
+		"shell_history": {Type: "boolean", Description: "交互命令行是否使用 ~/.bash_history、~/.zsh_history 作为提示"},
+		"notes":         {Type: "array", Description: "dora note 可复制的备忘", Items: &tools.Schema{Ref: "note"}},
+	},
+	Definitions: map[string]*tools.Schema{
+		"command": {
+			Type:     "object",
+			Required: []string{"value"},
+			Properties: map[string]*tools.Schema{
+				"value":             {Type: "string", Description: "要执行的命令，可使用 {{name}}、{{name=默认值}}、{{name:select(a,b)}}、{{name:choice(命令)}} 参数"},
+				"label":             {Type: "string", Description: "命令说明"},
+				"children":          {Type: "array", Description: "依次执行的步骤，默认某一步失败后不再执行后续步骤", Items: &tools.Schema{Ref: "command"}},
+				"id":                {Type: "string", Description: "步骤的标识，设置后记录输出，后续步骤可使用 {{steps.ID.output}}、{{steps.ID.exit_code}}，替换时已转义为单个参数"},
+				"continue_on_error": {Type: "boolean", Description: "步骤失败后继续执行后续步骤"},
+				"retry":             {Type: "integer", Description: "失败后的重试次数"},
+				"retry_delay_ms":    {Type: "integer", Description: "第一次重试前等待的毫秒数，之后每次翻倍，默认1000"},
+				"timeout_ms":        {Type: "integer", Description: "步骤的超时时间，超时后结束进程"},
+				"parallel":          {Type: "boolean", Description: "children同时执行，输出带有各自的前缀"},
+				"fail_fast":         {Type: "boolean", Description: "parallel分组中一项失败后取消其他的，默认执行完所有的再汇总错误"},
+				"when":              {Type: "string", Description: "执行条件：success（默认）、failure、always，或 steps.ID.exit_code == 0、steps.ID.output contains \"ok\""},
+			},
+		},
+		"prompt": {
+			Type:     "object",
+			Required: []string{"cmd"},
+			Properties: map[string]*tools.Schema{
+				"cmd":      {Type: "string", Description: "提示的命令，可使用 {{name}} 等参数"},
+				"label":    {Type: "string", Description: "命令说明"},
+				"children": {Type: "array", Description: "下一级的提示", Items: &tools.Schema{Ref: "prompt"}},
+			},
+		},
+		"note": {
+			Type:     "object",
+			Required: []string{"value"},
+			Properties: map[string]*tools.Schema{
+				"value": {Type: "string", Description: "备忘内容"},
+				"label": {Type: "string", Description: "备忘说明"},
+			},
+		},
+	},
+}
+
+// dora watch 使用的.dora.json
+var watchConfigSchema = &tools.Schema{
+	Title:       "dora watch config",
+	Description: "dora watch 使用的.dora.json",
+	Type:        "object",
+	Required:    []string{"watchers"},
+	Properties: map[string]*tools.Schema{
+		"watchers": {
+			Type:  "array",
+			Items: &tools.Schema{Ref: "watcher"},
+		},
+	},
+	Definitions: map[string]*tools.Schema{
+		"watcher": {
+			Type:     "object",
+			Required: []string{"include", "cmds"},
+			Properties: map[string]*tools.Schema{
+				"include":         stringListSchema("监听的目录或glob，相对配置文件所在目录，!开头为反向规则", 1, "path"),
+				"exclude":         stringListSchema("排除的目录或glob", 0, ""),
+				"cmds":            stringListSchema("文件变化时执行的命令", 1, ""),
+				"extensions":      stringListSchema("限定的文件后缀", 0, ""),
+				"gitignore":       {Type: "boolean", Description: "是否遵循.gitignore和.doraignore"},
+				"debounce_ms":     {Type: "integer", Description: "防抖时间，单位毫秒"},
+				"policy":          {Type: "string", Description: "运行中收到变化的处理策略", Enum: []string{policyCoalesce, policyQueue}},
+				"mode":            {Type: "string", Description: "执行模式", Enum: []string{modeRun, modeRestart}},
+				"kill_timeout_ms": {Type: "integer", Description: "停止或重启命令时等待进程退出的时间，单位毫秒"},
+				"cwd":             {Type: "string", Description: "命令执行的目录", Format: "path"},
+				"env":             {Type: "object", Description: "额外的环境变量", AdditionalProperties: &tools.Schema{Type: "string"}},
+				"template":        {Type: "boolean", Description: "是否渲染cmds中的{{.File}}等模板变量，默认true，命令中需要原样的{{}}时设置为false"},
+			},
+		},
+	},
+}
+
+// 根据配置内容选择对应的结构，包含watchers的为watch配置
+func detectConfigSchema(filePath string) *tools.Schema {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return globalConfigSchema
+	}
+	fields := map[string]json.RawMessage{}
+	if err := tools.DecodeConfig(filePath, data, &fields); err == nil {
+		if _, ok := fields["watchers"]; ok {
+			return watchConfigSchema
+		}
+	}
+	if strings.HasPrefix(filepath.Base(filePath), ".dora.") {
+		return watchConfigSchema
+	}
+	return globalConfigSchema
+}
@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/haokur/dora/tools"
+	"github.com/spf13/cobra"
+)
+
+var resolvedFlag bool
+
+// 列表项的展示文本
+func formatConfigItem(item interface{}) string {
+	fields, ok := item.(map[string]interface{})
+	if !ok {
+		data, _ := json.Marshal(item)
+		return string(data)
+	}
+	text := ""
+	for _, key := range []string{"value", "cmd"} {
+		if value, ok := fields[key].(string); ok {
+			text = value
+			break
+		}
+	}
+	if label, ok := fields["label"].(string); ok && label != "" {
+		text += fmt.Sprintf("（%s）", label)
+	}
+	return text
+}
+
+// 打印合并后的配置和每一项的来源
+func printResolvedConfig(resolved *tools.ResolvedConfig) {
+	fmt.Println("配置来源（优先级从低到高）：")
+	for i, layer := range resolved.Layers {
+		fmt.Printf("  %d. %s %s\n", i+1, layer.Name, layer.Path)
+	}
+	fmt.Println()
+
+	keys := []string{}
+	for key := range resolved.Data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		itemSources, hasSources := resolved.ItemSources[key]
+		items, isList := resolved.Data[key].([]interface{})
+		if !hasSources || !isList || len(itemSources) != len(items) {
+			data, _ := json.Marshal(resolved.Data[key])
+			fmt.Printf("%s: %s  \033[90m[%s]\033[0m\n", key, data, resolved.Sources[key])
+			continue
+		}
+		fmt.Printf("%s:\n", key)
+		for i, item := range items {
+			fmt.Printf("  - %s  \033[90m[%s]\033[0m\n", formatConfigItem(item), itemSources[i])
+		}
+	}
+}
+
+var configShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "查看配置，--resolved查看合并全局、项目、环境变量后的配置及来源",
+	Run: func(cmd *cobra.Command, args []string) {
+		if !resolvedFlag {
+			tools.PreviewFileWithSystemEditor(tools.GetDoraConfigPath())
+			return
+		}
+		resolved, err := tools.ResolveConfig()
+		if err != nil {
+			fmt.Println("读取配置失败:", err)
+			os.Exit(1)
+		}
+		printResolvedConfig(resolved)
+	},
+}
+
+func init() {
+	configShowCmd.Flags().BoolVarP(&resolvedFlag, "resolved", "r", false, "查看合并后的配置及来源")
+	configCmd.AddCommand(configShowCmd)
+}
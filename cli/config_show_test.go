@@ -0,0 +1,25 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/haokur/dora/tools"
+)
+
+func TestPrintResolvedConfig(t *testing.T) {
+	tests := []struct {
+		name        string
+		data        map[string]interface{}
+		itemSources map[string][]string
+	}{
+		{"列表和来源一一对应", map[string]interface{}{"commands": []interface{}{"a"}}, map[string][]string{"commands": {"global"}}},
+		{"来源对应的值不是列表", map[string]interface{}{"commands": "foo"}, map[string][]string{"commands": {"global"}}},
+		{"来源比列表项少", map[string]interface{}{"commands": []interface{}{"a", "b"}}, map[string][]string{"commands": {"global"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 不一致的来源按普通字段输出，不会panic
+			printResolvedConfig(&tools.ResolvedConfig{Data: tt.data, Sources: map[string]string{}, ItemSources: tt.itemSources})
+		})
+	}
+}
@@ -0,0 +1,365 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/haokur/dora/cmd"
+	"github.com/haokur/dora/tools"
+	"github.com/spf13/cobra"
+)
+
+// 命令行参数，优先于配置中的sync设置
+var syncBackend string
+var syncUrl string
+var syncToken string
+var syncRepo string
+var syncBranch string
+var syncPath string
+var syncForce bool
+var syncStrip bool
+
+type syncConfigType struct {
+	Api_key string             `json:"api_key"`
+	Sync    tools.SyncSettings `json:"sync"`
+}
+
+// 读取sync设置，命令行参数覆盖配置，http未设置token时使用api_key
+func getSyncBackend() (tools.SyncBackend, error) {
+	var config syncConfigType
+	// 只读取用户目录下的配置，项目配置和DORA_*环境变量不能修改同步地址，避免api_key发送到其他服务器
+	// 首次拉取时可能还没有配置文件，仅使用命令行参数
+	configPath := tools.GetDoraConfigPath()
+	if data, err := os.ReadFile(configPath); err == nil {
+		if err := tools.DecodeConfig(configPath, data, &config); err != nil {
+			return nil, fmt.Errorf("解析配置文件 %s 失败: %w", configPath, err)
+		}
+	}
+
+	settings := config.Sync
+	overrides := []struct {
+		value  string
+		target *string
+	}{
+		{syncBackend, &settings.Backend},
+		{syncUrl, &settings.Url},
+		{syncToken, &settings.Token},
+		{syncRepo, &settings.Repo},
+		{syncBranch, &settings.Branch},
+		{syncPath, &settings.Path},
+	}
+	for _, override := range overrides {
+		if override.value != "" {
+			*override.target = override.value
+		}
+	}
+	if settings.Token == "" {
+		settings.Token = config.Api_key
+	}
+	return tools.NewSyncBackend(settings)
+}
+
+// 解析配置内容为通用的map，内容为空时返回空配置
+func parseConfigMap(filePath string, content []byte) (map[string]interface{}, error) {
+	config := map[string]interface{}{}
+	if len(content) == 0 {
+		return config, nil
+	}
+	err := tools.DecodeConfig(filePath, content, &config)
+	return config, err
+}
+
+// 冲突项的展示内容
+func describeConflictValue(value interface{}) string {
+	if value == nil {
+		return "已删除"
+	}
+	return tools.CanonicalJSON(value)
+}
+
+// 本地和远程都修改了同一项时，让用户选择保留哪一边
+func resolveSyncConflict(conflict tools.MergeConflict) (interface{}, error) {
+	title := conflict.Section
+	if conflict.Id != "" {
+		title = fmt.Sprintf("%s「%s」", conflict.Section, conflict.Id)
+	}
+	options := []string{
+		"保留本地：" + describeConflictValue(conflict.Local),
+		"使用远程：" + describeConflictValue(conflict.Remote),
+	}
+	choice, err := cmd.Radio(fmt.Sprintf("%s 本地和远程都有修改，请选择", title), &options)
+	if err != nil {
+		return nil, err
+	}
+	switch choice {
+	case options[0]:
+		return conflict.Local, nil
+	case options[1]:
+		return conflict.Remote, nil
+	}
+	return nil, fmt.Errorf("已取消合并")
+}
+
+func marshalConfig(config map[string]interface{}, format string) ([]byte, error) {
+	jsonData, err := json.MarshalIndent(config, "", "    ")
+	if err != nil {
+		return nil, err
+	}
+	return tools.JSONToConfig(jsonData, format)
+}
+
+// 只显示密钥的开头，用于提示
+func maskSecretValue(value string) string {
+	if len(value) <= 4 {
+		return "****"
+	}
+	return value[:4] + "****"
+}
+
+// 去掉只保存在本地、不参与同步的api_key和sync.token，原配置不修改
+func withoutLocalSecrets(local map[string]interface{}) map[string]interface{} {
+	shared := map[string]interface{}{}
+	for key, value := range local {
+		if key != "api_key" {
+			shared[key] = value
+		}
+	}
+	if sync, ok := shared["sync"].(map[string]interface{}); ok {
+		settings := map[string]interface{}{}
+		for key, value := range sync {
+			if key != "token" {
+				settings[key] = value
+			}
+		}
+		shared["sync"] = settings
+	}
+	return shared
+}
+
+// 合并后放回本地的api_key和sync.token
+func restoreLocalSecrets(merged map[string]interface{}, local map[string]interface{}) {
+	if apiKey, ok := local["api_key"]; ok {
+		merged["api_key"] = apiKey
+	}
+	localSync, _ := local["sync"].(map[string]interface{})
+	token, ok := localSync["token"]
+	if !ok {
+		return
+	}
+	settings := map[string]interface{}{}
+	if mergedSync, ok := merged["sync"].(map[string]interface{}); ok {
+		for key, value := range mergedSync {
+			settings[key] = value
+		}
+	} else if merged["sync"] != nil {
+		return
+	}
+	settings["token"] = token
+	merged["sync"] = settings
+}
+
+// 生成用于推送的配置：去掉api_key和sync.token，检测其余字段中的密钥
+// 检测到密钥时拒绝推送，使用 --strip 时替换为 ${secret:NAME} 引用，密钥值保存在本地
+func publishableConfig(local map[string]interface{}) (map[string]interface{}, error) {
+	published := withoutLocalSecrets(local)
+	findings := tools.DetectSecrets(published)
+	if len(findings) == 0 {
+		return published, nil
+	}
+	for _, finding := range findings {
+		fmt.Printf("%s: %s %s\n", finding.Path, finding.Kind, maskSecretValue(finding.Value))
+	}
+	if !syncStrip {
+		err := fmt.Errorf("配置中包含密钥")
+		fmt.Printf("%v，请改为 ${secret:NAME} 引用（dora secret set NAME 保存密钥），或使用 --strip 自动替换后推送\n", err)
+		return nil, err
+	}
+	published, err := tools.StripSecrets(published, findings)
+	if err != nil {
+		fmt.Println("保存密钥失败:", err)
+		return nil, err
+	}
+	fmt.Println("以上密钥已替换为 ${secret:NAME} 引用后推送，密钥值保存在", tools.GetSecretsPath())
+	return published, nil
+}
+
+// 按本地配置文件的格式写入，yaml只修改有变化的部分，保留注释和键的顺序
+// toml重写后会丢失注释和顺序，确认后才写入
+func writeLocalConfig(localPath string, config map[string]interface{}) error {
+	existing, err := os.ReadFile(localPath)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	var content []byte
+	switch format := tools.DetectConfigFormat(localPath, existing); format {
+	case tools.FormatYAML:
+		content, err = tools.UpdateYAMLConfig(existing, config)
+	case tools.FormatTOML:
+		if len(existing) > 0 {
+			ok, confirmErr := cmd.Confirm(fmt.Sprintf("%s 为toml格式，合并后需要重写，原有的注释和顺序会丢失，是否继续", localPath), false)
+			if confirmErr != nil {
+				return confirmErr
+			}
+			if !ok {
+				return fmt.Errorf("已取消写入，本地配置未修改")
+			}
+		}
+		content, err = marshalConfig(config, format)
+	default:
+		content, err = marshalConfig(config, format)
+	}
+	if err != nil {
+		return err
+	}
+	return tools.WriteConfigWithHistory(localPath, content, "pull")
+}
+
+// 拉取配置，以上次同步的内容为基础，和本地配置三方合并
+func pullConfig() error {
+	backend, err := getSyncBackend()
+	if err != nil {
+		fmt.Println(err)
+		return err
+	}
+	remoteContent, err := backend.Pull()
+	if err != nil {
+		fmt.Printf("从 %s 拉取配置失败: %v\n", backend.Name(), err)
+		return err
+	}
+	remote, err := parseConfigMap("", remoteContent)
+	if err != nil {
+		fmt.Println("远程配置格式错误:", err)
+		return err
+	}
+
+	localPath := tools.GetDoraConfigPath()
+	merged := remote
+	localContent, err := os.ReadFile(localPath)
+	if err == nil {
+		local, err := parseConfigMap(localPath, localContent)
+		if err != nil {
+			fmt.Println("本地配置格式错误:", err)
+			return err
+		}
+		// 远程的配置不含api_key和sync.token，本地去掉后再比较，避免没有同步记录时总是冲突
+		base, _ := parseConfigMap("", tools.LoadSyncBase(backend))
+		merged, err = tools.ThreeWayMerge(base, withoutLocalSecrets(local), remote, resolveSyncConflict)
+		if err != nil {
+			fmt.Println("合并失败，本地配置未修改:", err)
+			return err
+		}
+		restoreLocalSecrets(merged, local)
+		if tools.CanonicalJSON(merged) == tools.CanonicalJSON(local) {
+			merged = nil
+		}
+	}
+
+	if merged != nil {
+		if err := writeLocalConfig(localPath, merged); err != nil {
+			fmt.Println("写入本地配置文件时出错:", err)
+			return err
+		}
+	}
+	if err := tools.SaveSyncBase(backend, []byte(tools.CanonicalJSON(remote))); err != nil {
+		fmt.Println("记录同步状态失败:", err)
+	}
+	fmt.Printf("从 %s 拉取配置成功: %s\n", backend.Name(), localPath)
+	return nil
+}
+
+// 推送配置，远程在上次同步后有更新时拒绝推送
+func pushConfig() error {
+	backend, err := getSyncBackend()
+	if err != nil {
+		fmt.Println(err)
+		return err
+	}
+	localPath := tools.GetDoraConfigPath()
+	content, err := os.ReadFile(localPath)
+	if err != nil {
+		fmt.Println("读取本地配置失败:", err)
+		return err
+	}
+	local, err := parseConfigMap(localPath, content)
+	if err != nil {
+		fmt.Println("本地配置格式错误:", err)
+		return err
+	}
+	published, err := publishableConfig(local)
+	if err != nil {
+		return err
+	}
+	content, err = marshalConfig(published, tools.DetectConfigFormat(localPath, content))
+	if err != nil {
+		fmt.Println(err)
+		return err
+	}
+
+	if !syncForce {
+		remoteContent, err := backend.Pull()
+		if err != nil && !errors.Is(err, tools.ErrSyncNotFound) {
+			fmt.Printf("无法获取 %s 的远程配置，使用 --force 强制推送: %v\n", backend.Name(), err)
+			return err
+		}
+		if err == nil {
+			remote, err := parseConfigMap("", remoteContent)
+			if err != nil {
+				fmt.Println("远程配置格式错误，使用 --force 覆盖:", err)
+				return err
+			}
+			base, _ := parseConfigMap("", tools.LoadSyncBase(backend))
+			remoteJson := tools.CanonicalJSON(remote)
+			if remoteJson != tools.CanonicalJSON(base) && remoteJson != tools.CanonicalJSON(published) {
+				err := fmt.Errorf("远程配置已在上次同步后更新")
+				fmt.Printf("%v，请先执行 dora config pull 合并，或使用 --force 覆盖\n", err)
+				return err
+			}
+		}
+	}
+
+	if err := backend.Push(content); err != nil {
+		fmt.Printf("推送配置到 %s 失败: %v\n", backend.Name(), err)
+		return err
+	}
+	if err := tools.SaveSyncBase(backend, []byte(tools.CanonicalJSON(published))); err != nil {
+		fmt.Println("记录同步状态失败:", err)
+	}
+	fmt.Printf("推送配置到 %s 成功\n", backend.Name())
+	return nil
+}
+
+var configPullCmd = &cobra.Command{
+	Use:   "pull",
+	Short: "从配置的同步方式（http、git、dir）拉取配置",
+	Run: func(cmd *cobra.Command, args []string) {
+		if pullConfig() != nil {
+			os.Exit(1)
+		}
+	},
+}
+
+var configPushCmd = &cobra.Command{
+	Use:   "push",
+	Short: "将配置推送到配置的同步方式（http、git、dir）",
+	Run: func(cmd *cobra.Command, args []string) {
+		if pushConfig() != nil {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	configPushCmd.Flags().BoolVarP(&syncForce, "force", "f", false, "远程有更新时强制覆盖")
+	configPushCmd.Flags().BoolVar(&syncStrip, "strip", false, "将检测到的密钥替换为 ${secret:NAME} 引用后推送")
+	for _, c := range []*cobra.Command{configPullCmd, configPushCmd} {
+		c.Flags().StringVar(&syncBackend, "backend", "", "同步方式，http、git、dir，默认使用配置中的sync.backend")
+		c.Flags().StringVar(&syncUrl, "url", "", "http同步的https地址")
+		c.Flags().StringVar(&syncToken, "token", "", "http同步的Bearer token，默认使用配置中的api_key")
+		c.Flags().StringVar(&syncRepo, "repo", "", "git同步的仓库地址")
+		c.Flags().StringVar(&syncBranch, "branch", "", "git同步的分支，默认main")
+		c.Flags().StringVar(&syncPath, "path", "", "git仓库中的文件路径，或dir同步的目录、文件")
+		configCmd.AddCommand(c)
+	}
+}
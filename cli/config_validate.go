@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/haokur/dora/tools"
+	"github.com/spf13/cobra"
+)
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate [配置文件...]",
+	Short: "校验配置文件，默认校验用户目录/dora/.config.json和当前目录的.dora.json",
+	Run: func(cmd *cobra.Command, args []string) {
+		files := args
+		if len(files) == 0 {
+			files = append(files, tools.GetDoraConfigPath())
+			if fileExists(".dora.json") {
+				files = append(files, ".dora.json")
+			}
+		}
+
+		hasError := false
+		for _, file := range files {
+			errs, err := tools.ValidateConfigFile(file, detectConfigSchema(file))
+			if err != nil {
+				fmt.Println("读取配置文件失败:", err)
+				hasError = true
+				continue
+			}
+			if len(errs) == 0 {
+				fmt.Printf("%s 校验通过\n", file)
+				continue
+			}
+			hasError = true
+			for _, e := range errs {
+				fmt.Printf("%s: %s\n", file, e)
+			}
+		}
+		if hasError {
+			os.Exit(1)
+		}
+	},
+}
+
+var configSchemaCmd = &cobra.Command{
+	Use:       "schema [global|watch]",
+	Short:     "输出配置文件的JSON Schema，global为用户目录/dora/.config.json，watch为.dora.json",
+	Args:      cobra.MaximumNArgs(1),
+	ValidArgs: []string{"global", "watch"},
+	Run: func(cmd *cobra.Command, args []string) {
+		schema := globalConfigSchema
+		if len(args) > 0 && args[0] == "watch" {
+			schema = watchConfigSchema
+		}
+		data, err := json.MarshalIndent(schema.JSONSchema(), "", "  ")
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		fmt.Println(string(data))
+	},
+}
+
+func init() {
+	configCmd.AddCommand(configValidateCmd)
+	configCmd.AddCommand(configSchemaCmd)
+}
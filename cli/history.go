@@ -0,0 +1,149 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/haokur/dora/cmd"
+	"github.com/haokur/dora/tools"
+	"github.com/spf13/cobra"
+)
+
+var historyProject bool
+var historySource string
+var historyFailed bool
+var historyLimit int
+var historyRerun bool
+
+// 路径中的用户目录显示为~
+func shortPath(path string) string {
+	homeDir := tools.GetUserHomePath()
+	if strings.HasPrefix(path, homeDir) {
+		return "~" + strings.TrimPrefix(path, homeDir)
+	}
+	return path
+}
+
+// 按条件筛选执行记录，返回的记录从新到旧
+func filterRunRecords(records []tools.RunRecord, keywords []string) []tools.RunRecord {
+	gitRoot := ""
+	if historyProject {
+		// 和记录时使用相同的方式查找，避免符号链接导致路径不同
+		gitRoot = tools.GitRootOf(tools.GetWorkDir())
+		if gitRoot == "" {
+			fmt.Println("当前目录不在git项目中")
+			os.Exit(1)
+		}
+	}
+
+	result := []tools.RunRecord{}
+	for i := len(records) - 1; i >= 0; i-- {
+		record := records[i]
+		if historyProject && record.GitRoot != gitRoot {
+			continue
+		}
+		if historySource != "" && record.Source != historySource {
+			continue
+		}
+		if historyFailed && record.ExitCode == 0 {
+			continue
+		}
+		matched := true
+		for _, keyword := range keywords {
+			if !strings.Contains(strings.ToLower(record.Command), strings.ToLower(keyword)) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			result = append(result, record)
+		}
+	}
+	return result
+}
+
+// 选择历史命令重新执行，在原来的目录中执行
+func rerunRecords(records []tools.RunRecord) {
+	items := []cmd.CommandItem{}
+	dirs := map[string]string{}
+	for _, record := range records {
+		if _, ok := dirs[record.Command]; ok {
+			continue
+		}
+		dirs[record.Command] = record.Cwd
+		items = append(items, cmd.CommandItem{
+			Label: record.Start.Format("01-02 15:04"),
+			Value: record.Command,
+			Desc:  shortPath(record.Cwd),
+		})
+	}
+	if len(items) == 0 {
+		fmt.Println("没有可执行的历史命令")
+		return
+	}
+
+	selected, err := cmd.Search(items)
+	if err != nil {
+		fmt.Println("cmd Search error", err)
+		return
+	}
+	tools.RunSource = "history"
+	for _, command := range selected {
+		if dir := dirs[command]; dir != "" {
+			if err := os.Chdir(dir); err != nil {
+				fmt.Printf("切换到目录 %s 失败: %v\n", dir, err)
+				continue
+			}
+		}
+		if err := tools.RunCommandWithLog(command); err != nil {
+			fmt.Println("执行失败", command, err)
+		}
+	}
+}
+
+var historyCmd = &cobra.Command{
+	Use:   "history [关键字...]",
+	Short: "查看通过dora执行过的命令，可搜索、筛选并选择重新执行",
+	Long:  "查看通过dora执行过的命令，记录保存在用户目录/dora/.run_history.jsonl\n例如：dora history git push -p\n或者：dora history -r",
+	Run: func(cobraCmd *cobra.Command, args []string) {
+		records, err := tools.ReadRunRecords()
+		if err != nil {
+			fmt.Println("读取执行记录失败:", err)
+			os.Exit(1)
+		}
+		records = filterRunRecords(records, args)
+
+		if historyRerun {
+			rerunRecords(records)
+			return
+		}
+		if len(records) == 0 {
+			fmt.Println("暂无执行记录")
+			return
+		}
+		if historyLimit > 0 && len(records) > historyLimit {
+			records = records[:historyLimit]
+		}
+		// 旧的在前，最新的显示在最后
+		for i := len(records) - 1; i >= 0; i-- {
+			record := records[i]
+			status := "✓"
+			if record.ExitCode != 0 {
+				status = fmt.Sprintf("✗ %d", record.ExitCode)
+			}
+			duration := record.End.Sub(record.Start).Round(time.Millisecond)
+			fmt.Printf("%s  %-5s %-8s %-7s %s  %s\n", record.Start.Format("2006-01-02 15:04:05"), status, duration, record.Source, shortPath(record.Cwd), record.Command)
+		}
+	},
+}
+
+func init() {
+	historyCmd.Flags().BoolVarP(&historyProject, "project", "p", false, "只显示当前git项目中执行的命令")
+	historyCmd.Flags().StringVarP(&historySource, "source", "s", "", "按来源筛选，prompt、cmd、cmd run、watch、history")
+	historyCmd.Flags().BoolVarP(&historyFailed, "failed", "f", false, "只显示执行失败的命令")
+	historyCmd.Flags().IntVarP(&historyLimit, "limit", "n", 50, "显示的数量，0为不限制")
+	historyCmd.Flags().BoolVarP(&historyRerun, "rerun", "r", false, "选择命令重新执行")
+	rootCmd.AddCommand(historyCmd)
+}
@@ -0,0 +1,54 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/haokur/dora/tools"
+)
+
+func TestFilterRunRecordsProject(t *testing.T) {
+	base, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	project := filepath.Join(base, "project")
+	if err := os.MkdirAll(filepath.Join(project, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(base, "link")
+	if err := os.Symlink(project, link); err != nil {
+		t.Skip("不支持符号链接:", err)
+	}
+	records := []tools.RunRecord{
+		{Command: "ls", GitRoot: tools.GitRootOf(link)},
+		{Command: "pwd", GitRoot: filepath.Join(base, "other")},
+		{Command: "date", GitRoot: project},
+	}
+
+	cwd, _ := os.Getwd()
+	historyProject = true
+	t.Cleanup(func() {
+		os.Chdir(cwd)
+		historyProject = false
+	})
+	tests := []struct {
+		name string
+		dir  string
+	}{
+		{"在项目目录中", project},
+		{"通过符号链接进入项目", link},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := os.Chdir(tt.dir); err != nil {
+				t.Fatal(err)
+			}
+			got := filterRunRecords(records, nil)
+			if len(got) != 2 || got[0].Command != "date" || got[1].Command != "ls" {
+				t.Errorf("筛选结果为 %+v，期望 date、ls 两条", got)
+			}
+		})
+	}
+}
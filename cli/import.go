@@ -0,0 +1,192 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/haokur/dora/cmd"
+	"github.com/haokur/dora/tools"
+	"github.com/spf13/cobra"
+)
+
+var importSection string
+var importTop int
+
+// 导入的一条命令
+type importItem struct {
+	label   string
+	command string
+	// 选择列表中显示的说明
+	desc string
+}
+
+// 各配置项中保存命令和名称的字段
+var importSectionFields = map[string][2]string{
+	"commands": {"value", "label"},
+	"prompts":  {"cmd", "label"},
+	"notes":    {"value", "label"},
+}
+
+// 根据命令生成名称，取开头不是选项的最多3个词
+func generateImportLabel(command string) string {
+	words := []string{}
+	for _, word := range strings.Fields(command) {
+		if strings.HasPrefix(word, "-") || strings.ContainsAny(word, "|&;<>") || len(words) >= 3 {
+			break
+		}
+		words = append(words, word)
+	}
+	if len(words) == 0 {
+		return command
+	}
+	return strings.Join(words, " ")
+}
+
+// 选择要导入的命令，写入用户配置的对应配置项，已有的命令跳过，同名的加序号
+func importItems(items []importItem) {
+	fields, ok := importSectionFields[importSection]
+	if !ok {
+		fmt.Println("不支持的配置项:", importSection, "可选 commands、prompts、notes")
+		os.Exit(1)
+	}
+	if len(items) == 0 {
+		fmt.Println("没有可导入的命令")
+		return
+	}
+
+	options := []string{}
+	for _, item := range items {
+		options = append(options, item.desc)
+	}
+	_, selected, err := cmd.Check(fmt.Sprintf("请选择要导入到 %s 的命令", importSection), &options, true)
+	if err != nil {
+		fmt.Println("选择失败:", err)
+		os.Exit(1)
+	}
+	if len(selected) == 0 {
+		fmt.Println("未选择任何命令")
+		return
+	}
+
+	configPath := tools.GetDoraConfigPath()
+	content, err := os.ReadFile(configPath)
+	if err != nil && !os.IsNotExist(err) {
+		fmt.Println("读取配置文件失败:", err)
+		os.Exit(1)
+	}
+	config, err := parseConfigMap(configPath, content)
+	if err != nil {
+		fmt.Println("解析配置文件失败:", err)
+		os.Exit(1)
+	}
+
+	valueKey, labelKey := fields[0], fields[1]
+	list, _ := config[importSection].([]interface{})
+	commands := map[string]bool{}
+	labels := map[string]bool{}
+	for _, entry := range list {
+		if item, ok := entry.(map[string]interface{}); ok {
+			if value, ok := item[valueKey].(string); ok {
+				commands[value] = true
+			}
+			if label, ok := item[labelKey].(string); ok {
+				labels[label] = true
+			}
+		}
+	}
+
+	added := []map[string]interface{}{}
+	for _, i := range selected {
+		item := items[i]
+		if commands[item.command] {
+			fmt.Println("已存在，跳过:", item.command)
+			continue
+		}
+		label := item.label
+		for n := 2; labels[label]; n++ {
+			label = fmt.Sprintf("%s %d", item.label, n)
+		}
+		commands[item.command] = true
+		labels[label] = true
+		entry := map[string]interface{}{labelKey: label, valueKey: item.command}
+		list = append(list, entry)
+		added = append(added, entry)
+	}
+	if len(added) == 0 {
+		return
+	}
+	config[importSection] = list
+
+	// yaml只修改导入的部分，toml只在末尾追加，保留原有的注释和顺序
+	var data []byte
+	switch tools.DetectConfigFormat(configPath, content) {
+	case tools.FormatYAML:
+		data, err = tools.UpdateYAMLConfig(content, config)
+	case tools.FormatTOML:
+		data, err = tools.AppendTOMLTables(content, importSection, added)
+		if err != nil {
+			fmt.Printf("%v，为避免丢失注释未修改配置文件，请改为 [[%s]] 的写法或手动添加\n", err, importSection)
+			os.Exit(1)
+		}
+	default:
+		data, err = marshalConfig(config, tools.FormatJSON)
+	}
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	if err := tools.WriteConfigWithHistory(configPath, data, "import"); err != nil {
+		fmt.Println("写入配置文件失败:", err)
+		os.Exit(1)
+	}
+	fmt.Printf("已导入 %d 条命令到 %s 的 %s\n", len(added), configPath, importSection)
+}
+
+var importCmd = &cobra.Command{
+	Use:   "import",
+	Short: "从shell配置和历史中导入命令",
+	Long:  "从shell配置和历史中导入命令，选择后写入用户配置的commands、prompts或notes\n例如：dora import aliases\n或者：dora import history --top 30 -s prompts",
+}
+
+var importAliasesCmd = &cobra.Command{
+	Use:   "aliases",
+	Short: "导入.bashrc、.zshrc等文件中的alias和简单函数",
+	Long:  "导入用户目录下.bashrc、.bash_aliases、.zshrc等文件中的alias和简单函数，名称使用别名\n函数的$1等参数转换为{{arg1}}模板参数，执行时输入",
+	Run: func(cobraCmd *cobra.Command, args []string) {
+		items := []importItem{}
+		for _, alias := range tools.ReadShellAliases() {
+			items = append(items, importItem{
+				label:   alias.Name,
+				command: alias.Command,
+				desc:    fmt.Sprintf("%s（%s）: %s", alias.Name, alias.Kind, alias.Command),
+			})
+		}
+		importItems(items)
+	},
+}
+
+var importHistoryCmd = &cobra.Command{
+	Use:   "history",
+	Short: "导入shell历史中执行次数最多的命令",
+	Long:  "导入shell历史中执行次数最多的命令，名称根据命令生成\n依次读取 $HISTFILE、~/.zsh_history、~/.bash_history",
+	Run: func(cobraCmd *cobra.Command, args []string) {
+		items := []importItem{}
+		for _, count := range tools.TopShellCommands(importTop) {
+			items = append(items, importItem{
+				label:   generateImportLabel(count.Command),
+				command: count.Command,
+				desc:    fmt.Sprintf("%4d次  %s", count.Count, count.Command),
+			})
+		}
+		importItems(items)
+	},
+}
+
+func init() {
+	importCmd.PersistentFlags().StringVarP(&importSection, "section", "s", "commands", "写入的配置项，commands、prompts、notes")
+	importHistoryCmd.Flags().IntVarP(&importTop, "top", "n", 20, "导入次数最多的前N条")
+	importCmd.AddCommand(importAliasesCmd)
+	importCmd.AddCommand(importHistoryCmd)
+	rootCmd.AddCommand(importCmd)
+}
@@ -0,0 +1,142 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/haokur/dora/cmd"
+	"github.com/haokur/dora/configs"
+	"github.com/haokur/dora/tools"
+	"github.com/spf13/cobra"
+)
+
+// 可选择的初始配置项
+var initSections = []string{"commands", "prompts", "notes"}
+
+var initTemplate string
+var initSectionList []string
+var initFormat string
+var initForce bool
+
+// 读取初始化模板，支持http(s)地址和本地文件，为空时使用内置配置
+func readInitTemplate(template string) ([]byte, error) {
+	if template == "" {
+		return configs.DefaultConfig, nil
+	}
+	if !strings.HasPrefix(template, "http://") && !strings.HasPrefix(template, "https://") {
+		return os.ReadFile(template)
+	}
+
+	client := http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Get(template)
+	if err != nil {
+		return nil, fmt.Errorf("无法获取远程模板: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("请求失败，状态码: %d", resp.StatusCode)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// 选择要包含的配置项，未指定时交互选择，无法交互时全部包含
+func selectInitSections(template map[string]interface{}) []string {
+	if len(initSectionList) > 0 {
+		return initSectionList
+	}
+	options := []string{}
+	for _, section := range initSections {
+		if _, ok := template[section]; ok {
+			options = append(options, section)
+		}
+	}
+	if len(options) == 0 {
+		return options
+	}
+	selected, _, err := cmd.Check("请选择要包含的初始配置", &options, true)
+	if err != nil {
+		fmt.Println("无法交互选择，包含全部配置:", err)
+		return options
+	}
+	return selected
+}
+
+var initCmd = &cobra.Command{
+	Use:   "init",
+	Short: "初始化配置文件，默认使用内置配置，可指定模板地址或文件",
+	Long:  "初始化配置文件，默认使用内置配置，可指定模板地址或文件\n例如：dora init -s commands,notes\n或者：dora init -t https://example.com/dora.config.json --format yaml",
+	Run: func(cobraCmd *cobra.Command, args []string) {
+		configPath := tools.GetDoraConfigPath()
+		if _, err := os.Stat(configPath); err == nil && !initForce {
+			overwrite, err := cmd.Confirm(fmt.Sprintf("配置文件 %s 已存在，是否覆盖", configPath), false)
+			if err != nil || !overwrite {
+				return
+			}
+		}
+
+		data, err := readInitTemplate(initTemplate)
+		if err != nil {
+			fmt.Println("读取模板失败:", err)
+			os.Exit(1)
+		}
+		template := map[string]interface{}{}
+		if err := tools.DecodeConfig(initTemplate, data, &template); err != nil {
+			fmt.Println("解析模板失败:", err)
+			os.Exit(1)
+		}
+
+		// 模板中的非列表配置保留，列表配置只保留选择的
+		config := map[string]interface{}{}
+		for key, value := range template {
+			if !tools.SliceContains(initSections, key) {
+				config[key] = value
+			}
+		}
+		for _, section := range selectInitSections(template) {
+			if value, ok := template[section]; ok {
+				config[section] = value
+			}
+		}
+
+		jsonData, err := json.MarshalIndent(config, "", "    ")
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		content, err := tools.JSONToConfig(jsonData, initFormat)
+		if err != nil {
+			fmt.Println("生成配置失败:", err)
+			os.Exit(1)
+		}
+
+		// 写入新格式的文件，移除其他格式的旧配置，避免读取到旧的
+		newConfigPath := filepath.Join(filepath.Dir(configPath), ".config."+initFormat)
+		if newConfigPath != configPath {
+			if _, err := tools.SaveConfigSnapshot(configPath, "backup"); err != nil {
+				fmt.Println("保存配置快照失败:", err)
+			}
+		}
+		if err := tools.WriteConfigWithHistory(newConfigPath, content, "init"); err != nil {
+			fmt.Println("写入配置文件失败:", err)
+			os.Exit(1)
+		}
+		if newConfigPath != configPath {
+			os.Remove(configPath)
+		}
+		fmt.Println("配置文件生成成功:", newConfigPath)
+	},
+}
+
+func init() {
+	initCmd.Flags().StringVarP(&initTemplate, "template", "t", "", "模板地址或文件，默认使用内置配置")
+	initCmd.Flags().StringSliceVarP(&initSectionList, "sections", "s", []string{}, "包含的配置项，可选commands、prompts、notes，默认交互选择")
+	initCmd.Flags().StringVar(&initFormat, "format", tools.FormatJSON, "配置文件格式，json、yaml、toml")
+	initCmd.Flags().BoolVarP(&initForce, "force", "f", false, "已存在时直接覆盖")
+	rootCmd.AddCommand(initCmd)
+}
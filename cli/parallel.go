@@ -0,0 +1,225 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"syscall"
+
+	"github.com/haokur/dora/tools"
+)
+
+// 并行执行时被取消的步骤状态
+const stepCanceled = "已取消"
+
+// 输出前缀的颜色
+var prefixColors = []string{"36", "32", "33", "35", "34", "31"}
+
+// 按行给输出加上前缀，多个进程共用一把锁，避免输出交错
+type prefixWriter struct {
+	mu     *sync.Mutex
+	out    io.Writer
+	prefix string
+	buf    []byte
+}
+
+func (w *prefixWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i == -1 {
+			break
+		}
+		w.writeLine(w.buf[:i+1])
+		w.buf = w.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+// 输出最后不以换行结尾的内容
+func (w *prefixWriter) Flush() {
+	if len(w.buf) > 0 {
+		w.writeLine(append(w.buf, '\n'))
+		w.buf = nil
+	}
+}
+
+func (w *prefixWriter) writeLine(line []byte) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	fmt.Fprintf(w.out, "%s %s", w.prefix, line)
+}
+
+// 同时记录stdout和stderr的输出
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// 并行执行的一项，按步骤执行，有children时作为嵌套的分组
+type parallelJob struct {
+	item     cmdJsonItem
+	skip     bool
+	result   *stepResult
+	workflow *workflow
+	stdout   *prefixWriter
+	stderr   *prefixWriter
+}
+
+// 依次询问所有命令中的参数，并行执行时不能同时交互
+func expandItems(items []cmdJsonItem) ([]cmdJsonItem, error) {
+	expanded := make([]cmdJsonItem, 0, len(items))
+	for _, item := range items {
+		if item.Value != "" {
+			command, err := expandCommand(item.Value)
+			if err != nil {
+				return nil, err
+			}
+			item.Value = command
+		}
+		if len(item.Children) > 0 {
+			children, err := expandItems(item.Children)
+			if err != nil {
+				return nil, err
+			}
+			item.Children = children
+		}
+		expanded = append(expanded, item)
+	}
+	return expanded, nil
+}
+
+// 并行执行多条命令，每一项和顺序执行的步骤一样支持timeout_ms、retry、when、id和嵌套的分组
+// limit为同时执行的数量，0为不限制，when和之前步骤的结果在开始前确定
+// failFast为true时一项失败后取消其他的，否则执行完所有的再汇总错误，Ctrl+C取消所有正在执行的命令
+func runParallel(parent *workflow, items []cmdJsonItem, limit int, failFast bool) ([]*stepResult, error) {
+	if limit <= 0 || limit > len(items) {
+		limit = len(items)
+	}
+	names := []string{}
+	for _, item := range items {
+		names = append(names, stepName(item))
+	}
+	if !parent.expanded {
+		var err error
+		items, err = expandItems(items)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	ctx, cancel := context.WithCancel(parent.ctx)
+	defer cancel()
+	var outputMu sync.Mutex
+	jobs := []*parallelJob{}
+	for i, item := range items {
+		prefix := fmt.Sprintf("\033[%sm[%s]\033[0m", prefixColors[i%len(prefixColors)], names[i])
+		job := &parallelJob{
+			item:   item,
+			result: &stepResult{name: names[i], exitCode: -1},
+			stdout: &prefixWriter{mu: &outputMu, out: parent.stdout, prefix: prefix},
+			stderr: &prefixWriter{mu: &outputMu, out: parent.stderr, prefix: prefix},
+		}
+		run, err := parent.shouldRun(item)
+		if err != nil {
+			fmt.Fprintf(parent.stdout, "步骤 %s 的when条件错误: %v\n", names[i], err)
+		}
+		job.skip = !run
+		// 可以引用之前步骤的结果
+		job.workflow = parent.child()
+		for id, result := range parent.byId {
+			job.workflow.byId[id] = result
+		}
+		job.workflow.ctx = ctx
+		job.workflow.stdout = job.stdout
+		job.workflow.stderr = job.stderr
+		job.workflow.pty = false
+		job.workflow.expanded = true
+		jobs = append(jobs, job)
+	}
+
+	signals := make(chan os.Signal, 1)
+	defer tools.NotifySignals(signals, os.Interrupt, syscall.SIGTERM)()
+	go func() {
+		select {
+		case <-signals:
+			fmt.Fprintln(parent.stdout, "\n正在取消所有命令...")
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	sem := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+	for _, job := range jobs {
+		wg.Add(1)
+		go func(job *parallelJob) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			job.run()
+			if failFast && job.failed() {
+				cancel()
+			}
+		}(job)
+	}
+	wg.Wait()
+
+	results := []*stepResult{}
+	failed := 0
+	for _, job := range jobs {
+		results = append(results, job.result)
+		if job.failed() {
+			failed++
+		}
+		if job.item.Id != "" {
+			parent.byId[job.item.Id] = job.result
+		}
+	}
+	if !cmdJsonOutput {
+		summary := parent.child()
+		summary.results = results
+		summary.printSummary()
+	}
+	if failed > 0 {
+		return results, fmt.Errorf("%d 项执行失败或已取消", failed)
+	}
+	return results, nil
+}
+
+func (job *parallelJob) run() {
+	defer func() {
+		job.stdout.Flush()
+		job.stderr.Flush()
+	}()
+	if job.skip {
+		job.result.status = stepSkipped
+		return
+	}
+	if job.workflow.ctx.Err() != nil {
+		job.result.status = stepCanceled
+		return
+	}
+	job.workflow.runStep(job.item, job.result)
+}
+
+// 执行失败、超时或者被取消，设置了continue_on_error的不算失败
+func (job *parallelJob) failed() bool {
+	status := job.result.status
+	return status != stepSuccess && status != stepSkipped && !job.item.ContinueOnError
+}
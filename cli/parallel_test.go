@@ -0,0 +1,32 @@
+package cli
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+)
+
+func TestPrefixWriter(t *testing.T) {
+	tests := []struct {
+		name   string
+		writes []string
+		want   string
+	}{
+		{"按行加前缀", []string{"a\nb\n"}, "[x] a\n[x] b\n"},
+		{"跨多次写入的行", []string{"he", "llo\nwor", "ld\n"}, "[x] hello\n[x] world\n"},
+		{"最后没有换行的内容在Flush时输出", []string{"a\nb"}, "[x] a\n[x] b\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			w := &prefixWriter{mu: &sync.Mutex{}, out: &out, prefix: "[x]"}
+			for _, s := range tt.writes {
+				w.Write([]byte(s))
+			}
+			w.Flush()
+			if out.String() != tt.want {
+				t.Errorf("输出为 %q，期望 %q", out.String(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+//go:build !windows
+
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRunParallel(t *testing.T) {
+	tests := []struct {
+		name     string
+		items    func(marker string) []cmdJsonItem
+		failFast bool
+		// 期望的错误，为空时期望成功
+		wantErr string
+		// 是否执行到了创建标记文件的命令
+		wantMarker bool
+	}{
+		{
+			name: "全部成功",
+			items: func(marker string) []cmdJsonItem {
+				return []cmdJsonItem{{Value: "true"}, {Value: "sleep 0.1 && touch " + marker}}
+			},
+			wantMarker: true,
+		},
+		{
+			name: "不启用fail-fast时执行完所有再汇总",
+			items: func(marker string) []cmdJsonItem {
+				return []cmdJsonItem{{Value: "exit 1"}, {Value: "exit 2"}, {Value: "sleep 0.2 && touch " + marker}}
+			},
+			wantErr:    "2 项执行失败或已取消",
+			wantMarker: true,
+		},
+		{
+			name: "fail-fast取消正在执行的命令",
+			items: func(marker string) []cmdJsonItem {
+				return []cmdJsonItem{{Value: "exit 1"}, {Value: "sleep 30 && touch " + marker}}
+			},
+			failFast: true,
+			wantErr:  "2 项执行失败或已取消",
+		},
+		{
+			name: "children中失败的命令之后不再执行",
+			items: func(marker string) []cmdJsonItem {
+				return []cmdJsonItem{{Label: "组", Children: []cmdJsonItem{{Value: "false"}, {Value: "touch " + marker}}}, {Value: "true"}}
+			},
+			wantErr: "1 项执行失败或已取消",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			marker := filepath.Join(t.TempDir(), "done")
+			start := time.Now()
+			_, err := runParallel(newWorkflow(), tt.items(marker), 0, tt.failFast)
+			if elapsed := time.Since(start); elapsed > 5*time.Second {
+				t.Errorf("执行用时 %s，期望及时取消", elapsed)
+			}
+			if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr) {
+				t.Errorf("runParallel() 错误为 %v，期望 %q", err, tt.wantErr)
+			}
+			if _, err := os.Stat(marker); (err == nil) != tt.wantMarker {
+				t.Errorf("标记文件存在为 %v，期望 %v", err == nil, tt.wantMarker)
+			}
+		})
+	}
+}
@@ -1,9 +1,12 @@
 package cli
 
 import (
+	"errors"
 	"fmt"
+	"log"
 	"os"
 	"strings"
+	"time"
 
 	prompt "github.com/c-bata/go-prompt"
 	"github.com/haokur/dora/tools"
@@ -18,12 +21,19 @@ func getPrefix() string {
 	return prefix
 }
 
+// 交互命令行使用的shell会话，不支持时为nil，每条命令单独执行
+var shellSession *tools.ShellSession
+
 func createPrompt() *prompt.Prompt {
 	prefix := getPrefix()
 	return prompt.New(
 		executor,
 		completer,
 		prompt.OptionPrefix(prefix),
+		// 目录变化后直接更新前缀
+		prompt.OptionLivePrefix(func() (string, bool) {
+			return getPrefix(), true
+		}),
 		prompt.OptionTitle("dora命令行工具"),
 		prompt.OptionPrefixTextColor(prompt.DarkBlue),
 		prompt.OptionPreviewSuggestionTextColor(prompt.Blue),
@@ -32,21 +42,56 @@ func createPrompt() *prompt.Prompt {
 	)
 }
 
+func exitPrompt() {
+	if shellSession != nil {
+		shellSession.Close()
+	}
+	tools.CleanupProcesses()
+	fmt.Println("再见！")
+	os.Exit(0)
+}
+
+// 在shell会话中执行命令，记录日志和执行记录
+func runInSession(command string) error {
+	log.Println("执行命令：", command)
+	start := time.Now()
+	dir := tools.GetWorkDir()
+	resolved, _, err := tools.ResolveSecrets(command)
+	if err != nil {
+		fmt.Println(err)
+		return err
+	}
+	exitCode, err := shellSession.Run(resolved)
+	if err != nil {
+		return err
+	}
+	tools.RecordRun(command, dir, start, exitCode, "")
+	return nil
+}
+
 func executor(t string) {
 	if t == "dora" {
 		return
 	}
 	if t == "exit" {
-		fmt.Println("再见！")
-		os.Exit(0)
-	}
-	if t != "" {
-		err := tools.RunCommandWithLog(t)
-		if err == nil && strings.HasPrefix(t, "cd") {
-			p := createPrompt()
-			p.Run()
-		}
+		exitPrompt()
 	}
+	if t == "" {
+		return
+	}
+	t, err := expandTemplate(t)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	if shellSession == nil {
+		tools.RunCommandWithLog(t)
+	} else if err := runInSession(t); errors.Is(err, tools.ErrSessionClosed) {
+		// 命令中执行了exit
+		exitPrompt()
+	}
+	recordSuggest(t)
+	clearDynamicCache()
 }
 
 type promptItem struct {
@@ -56,11 +101,74 @@ type promptItem struct {
 }
 
 type promptJsonType struct {
-	Prompts []promptItem `json:"prompts"`
+	Prompts      []promptItem `json:"prompts"`
+	ShellHistory bool         `json:"shell_history"`
 }
 
 var jsonConfig promptJsonType
 
+// 最多显示的提示数量
+const maxSuggestions = 50
+
+// 作为提示的历史命令数量
+const historySuggestLimit = 500
+
+// 一条提示，Label用于中文搜索，Desc为显示的说明
+type suggestItem struct {
+	Cmd   string
+	Label string
+	Desc  string
+}
+
+// 提示的来源：配置的prompts、dora执行过的命令、开启shell_history时的shell历史
+var suggestItems []suggestItem
+
+// 每条命令的frecency，执行越近、越频繁的越靠前
+var commandFrecency map[string]float64
+
+func loadSuggestItems() {
+	records, _ := tools.ReadRunRecords()
+	commandFrecency = tools.Frecency(records, time.Now())
+
+	seen := map[string]bool{}
+	add := func(item suggestItem) {
+		if item.Cmd == "" || seen[item.Cmd] {
+			return
+		}
+		seen[item.Cmd] = true
+		suggestItems = append(suggestItems, item)
+	}
+	for _, item := range jsonConfig.Prompts {
+		add(suggestItem{Cmd: item.Cmd, Label: item.Label, Desc: item.Label})
+	}
+	for i, count := len(records)-1, 0; i >= 0 && count < historySuggestLimit; i-- {
+		if !seen[records[i].Command] {
+			add(suggestItem{Cmd: records[i].Command, Desc: "历史"})
+			count++
+		}
+	}
+	if jsonConfig.ShellHistory {
+		for _, command := range tools.ReadShellHistory(historySuggestLimit) {
+			add(suggestItem{Cmd: command, Desc: "shell"})
+		}
+	}
+}
+
+// 执行命令后更新frecency，新命令加入提示
+func recordSuggest(command string) {
+	commandFrecency[command] += tools.FrecencyWeight(0)
+	for _, item := range suggestItems {
+		if item.Cmd == command {
+			return
+		}
+	}
+	suggestItems = append(suggestItems, suggestItem{Cmd: command, Desc: "历史"})
+}
+
+func suggestBonus(item suggestItem) int {
+	return tools.FrecencyBonus(commandFrecency[item.Cmd])
+}
+
 func getSuggestions(input string, commands []promptItem) []prompt.Suggest {
 	parts := strings.Fields(input)
 	if len(parts) == 0 {
@@ -95,9 +203,12 @@ func completer(t prompt.Document) []prompt.Suggest {
 	// 比如无空格，输入gip，能匹配到建议：git push origin main
 	// 如果有空格，比如git push，则能匹配到 origin main
 	// 如果t.Text为git push origin，则能匹配到main
+	// 按模糊匹配的得分和执行的frecency排序
+	// 路径、git分支等动态提示排在最前面
 	promptConfig := jsonConfig.Prompts
 	searchKey := strings.TrimLeft(t.Text, " ")
-	suggestions := make([]prompt.Suggest, 0, len(promptConfig))
+	suggestions := make([]prompt.Suggest, 0, maxSuggestions)
+	suggestions = append(suggestions, getDynamicSuggestions(t)...)
 	matchFieldKey := "Cmd"
 	if tools.ContainsChineseWords(searchKey) {
 		matchFieldKey = "Label"
@@ -116,13 +227,16 @@ func completer(t prompt.Document) []prompt.Suggest {
 		afterLastSpaceCmd = strings.Trim(afterLastSpaceCmd, "")
 		filterChildrenCmds := subSuggestList
 		if afterLastSpaceCmd != "" {
-			filterChildrenCmds = tools.FindMatches(subSuggestList, "Text", afterLastSpaceCmd)
+			filterChildrenCmds = tools.RankMatches(subSuggestList, "Text", afterLastSpaceCmd, nil)
 		}
 		suggestions = append(suggestions, filterChildrenCmds...)
 	}
 
-	matches := tools.FindMatches(promptConfig, matchFieldKey, searchKey)
+	matches := tools.RankMatches(suggestItems, matchFieldKey, searchKey, suggestBonus)
 	for _, item := range matches {
+		if len(suggestions) >= maxSuggestions {
+			break
+		}
 		command := item.Cmd
 		if searchKeyHasSpace {
 			// 替换最后一个空格前面所有内容
@@ -130,7 +244,7 @@ func completer(t prompt.Document) []prompt.Suggest {
 		}
 		suggestions = append(suggestions, prompt.Suggest{
 			Text:        command,
-			Description: item.Label,
+			Description: item.Desc,
 		})
 	}
 
@@ -142,10 +256,18 @@ var rootCmd = &cobra.Command{
 	Short: "效率自动化工具箱",
 	Long:  "基于Golang+Cobra开发的效率自动化工具箱\n不带参数进入带提示的交互页面",
 	Run: func(cmd *cobra.Command, args []string) {
+		tools.RunSource = "prompt"
 		if err := tools.ReadDoraJsonConfig(&jsonConfig); err != nil {
 			fmt.Println("ReadJsonError", err)
 			os.Exit(1)
 		}
+		loadSuggestItems()
+
+		session, err := tools.NewShellSession()
+		if err != nil {
+			log.Println("无法启动shell会话，每条命令单独执行:", err)
+		}
+		shellSession = session
 
 		// 缺省不带参数，则进入dora环境，使用go-prompt进行提示
 		p := createPrompt()
@@ -155,7 +277,9 @@ var rootCmd = &cobra.Command{
 }
 
 func Execute() {
+	tools.InitProcessManager()
 	err := rootCmd.Execute()
+	tools.CleanupProcesses()
 	if err != nil {
 		os.Exit(1)
 	}
@@ -0,0 +1,124 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/haokur/dora/tools"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// 读取密钥值，终端中输入时不回显，也支持从管道读取
+func readSecretValue(name string) (string, error) {
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		fmt.Printf("请输入 %s 的值: ", name)
+		value, err := term.ReadPassword(int(os.Stdin.Fd()))
+		fmt.Println()
+		return string(value), err
+	}
+	value, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && value == "" {
+		return "", err
+	}
+	return strings.TrimRight(value, "\r\n"), nil
+}
+
+var secretCmd = &cobra.Command{
+	Use:   "secret",
+	Short: "管理本地密钥，配置中使用 ${secret:NAME} 引用，执行命令时替换",
+	Long:  "管理本地密钥，保存在用户目录/dora/.secrets.json，不参与配置同步\n配置中使用 ${secret:NAME} 引用，执行命令时替换\n例如：dora secret set GITHUB_TOKEN",
+}
+
+var secretSetCmd = &cobra.Command{
+	Use:   "set NAME [value]",
+	Short: "保存密钥，未指定值时从输入读取",
+	Args:  cobra.RangeArgs(1, 2),
+	Run: func(cobraCmd *cobra.Command, args []string) {
+		name := args[0]
+		value := ""
+		if len(args) == 2 {
+			value = args[1]
+		} else {
+			var err error
+			value, err = readSecretValue(name)
+			if err != nil {
+				fmt.Println("读取密钥失败:", err)
+				os.Exit(1)
+			}
+		}
+		if value == "" {
+			fmt.Println("密钥的值不能为空")
+			os.Exit(1)
+		}
+
+		secrets, err := tools.LoadSecrets()
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		secrets[name] = value
+		if err := tools.SaveSecrets(secrets); err != nil {
+			fmt.Println("保存密钥失败:", err)
+			os.Exit(1)
+		}
+		fmt.Printf("已保存密钥 %s，在配置中使用 ${secret:%s} 引用\n", name, name)
+	},
+}
+
+var secretListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "列出已保存的密钥名称",
+	Run: func(cobraCmd *cobra.Command, args []string) {
+		secrets, err := tools.LoadSecrets()
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		if len(secrets) == 0 {
+			fmt.Println("暂无密钥")
+			return
+		}
+		names := make([]string, 0, len(secrets))
+		for name := range secrets {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Println(name)
+		}
+	},
+}
+
+var secretRemoveCmd = &cobra.Command{
+	Use:   "rm NAME",
+	Short: "删除密钥",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cobraCmd *cobra.Command, args []string) {
+		secrets, err := tools.LoadSecrets()
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		if _, ok := secrets[args[0]]; !ok {
+			fmt.Println("密钥不存在:", args[0])
+			os.Exit(1)
+		}
+		delete(secrets, args[0])
+		if err := tools.SaveSecrets(secrets); err != nil {
+			fmt.Println("保存密钥失败:", err)
+			os.Exit(1)
+		}
+		fmt.Println("已删除密钥", args[0])
+	},
+}
+
+func init() {
+	secretCmd.AddCommand(secretSetCmd)
+	secretCmd.AddCommand(secretListCmd)
+	secretCmd.AddCommand(secretRemoveCmd)
+	rootCmd.AddCommand(secretCmd)
+}
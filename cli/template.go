@@ -0,0 +1,156 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/haokur/dora/cmd"
+	"github.com/haokur/dora/tools"
+)
+
+// 每个参数保留的历史输入数量
+const templateHistoryLimit = 10
+
+// 取消输入参数时返回
+var errTemplateCanceled = fmt.Errorf("已取消执行")
+
+// 参数的历史输入，位于用户目录/dora/.template_history.json，最近的在前
+func getTemplateHistoryPath() string {
+	return filepath.Join(tools.GetDoraConfigDir(), ".template_history.json")
+}
+
+func loadTemplateHistory() map[string][]string {
+	history := map[string][]string{}
+	if err := tools.ReadJsonFile(getTemplateHistoryPath(), &history); err != nil {
+		return map[string][]string{}
+	}
+	return history
+}
+
+func saveTemplateHistory(history map[string][]string) error {
+	content, err := json.MarshalIndent(history, "", "    ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(tools.GetDoraConfigDir(), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(getTemplateHistoryPath(), content, 0644)
+}
+
+// 将历史值放到选项的最前面
+func prependHistory(options []string, recent []string) []string {
+	result := []string{}
+	for _, value := range recent {
+		if tools.SliceContains(options, value) && !tools.SliceContains(result, value) {
+			result = append(result, value)
+		}
+	}
+	for _, option := range options {
+		if !tools.SliceContains(result, option) {
+			result = append(result, option)
+		}
+	}
+	return result
+}
+
+// 获取参数的选项
+func templateOptions(param tools.TemplateParam) ([]string, error) {
+	options := []string{}
+	if param.Kind == "select" {
+		for _, option := range strings.Split(param.Arg, ",") {
+			if option = strings.TrimSpace(option); option != "" {
+				options = append(options, option)
+			}
+		}
+		return options, nil
+	}
+	output, err := tools.RunCommand(param.Arg)
+	if err != nil {
+		return nil, fmt.Errorf("获取 %s 的选项失败: %w", param.Name, err)
+	}
+	for _, line := range strings.Split(output, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			options = append(options, line)
+		}
+	}
+	return options, nil
+}
+
+// 依次询问命令中的参数并替换，没有参数时原样返回
+func expandTemplate(command string) (string, error) {
+	params := tools.ParseTemplateParams(command)
+	if len(params) == 0 {
+		return command, nil
+	}
+
+	history := loadTemplateHistory()
+	values := map[string]string{}
+	for _, param := range params {
+		value, ok := values[param.Name]
+		if !ok {
+			var err error
+			value, err = askTemplateParam(param, history[param.Name])
+			if err != nil {
+				return "", err
+			}
+			values[param.Name] = value
+			history[param.Name] = append([]string{value}, removeString(history[param.Name], value)...)
+			if len(history[param.Name]) > templateHistoryLimit {
+				history[param.Name] = history[param.Name][:templateHistoryLimit]
+			}
+		}
+		command = strings.ReplaceAll(command, param.Raw, value)
+	}
+	if err := saveTemplateHistory(history); err != nil {
+		fmt.Println("保存参数历史失败:", err)
+	}
+	return command, nil
+}
+
+func askTemplateParam(param tools.TemplateParam, recent []string) (string, error) {
+	if param.Kind == "input" {
+		defaultValue := param.Default
+		if len(recent) > 0 {
+			defaultValue = recent[0]
+		}
+		value, err := cmd.Input(fmt.Sprintf("请输入 %s", param.Name), defaultValue)
+		if err != nil {
+			return "", err
+		}
+		if value == "" {
+			return "", errTemplateCanceled
+		}
+		return value, nil
+	}
+
+	options, err := templateOptions(param)
+	if err != nil {
+		return "", err
+	}
+	if len(options) == 0 {
+		return "", fmt.Errorf("%s 没有可选项", param.Name)
+	}
+	options = prependHistory(options, recent)
+	value, err := cmd.Radio(fmt.Sprintf("请选择 %s", param.Name), &options)
+	if err != nil {
+		return "", err
+	}
+	if value == "" {
+		return "", errTemplateCanceled
+	}
+	return value, nil
+}
+
+func removeString(slice []string, value string) []string {
+	result := []string{}
+	for _, item := range slice {
+		if item != value {
+			result = append(result, item)
+		}
+	}
+	return result
+}
@@ -1,15 +1,13 @@
 package cli
 
 import (
-	"encoding/json"
 	"fmt"
-	"log"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
-	"time"
+	"syscall"
 
+	"github.com/haokur/dora/tools"
 	"github.com/spf13/cobra"
 	"gopkg.in/fsnotify.v1"
 )
@@ -20,7 +18,14 @@ import (
 3. 监听变化，执行配置的要执行的方法
 4. TODO://提供go常量和方法，供配置，如sleep，ip地址
 5. 可指定配置目录
-6. 5s内相同的命令仅执行一次
+6. 每个watcher独立调度，debounce_ms内的连续变化只执行一次，以最后一次变化为准
+7. 命令运行中收到的变化，按policy合并（coalesce）或排队（queue）
+8. mode为restart时，最后一条命令作为常驻进程，变化时结束整个进程组后重启
+9. include/exclude支持相对配置文件的路径和glob，include中!开头为反向规则，gitignore为true时遵循.gitignore/.doraignore
+10. template为true时cmds中可使用{{.File}}、{{.Files}}、{{.Op}}、{{.RelFile}}获取触发的文件，也可读取DORA_CHANGED_*环境变量
+11. 命令通过shell执行，支持管道、&&和引号，cwd、env按watcher配置，cd只影响当前watcher
+12. 运行中修改配置文件会自动重新加载，只重启有变化的watcher，配置有误时保留原配置
+13. 配置文件支持json、yaml（-c .dora.yaml）、toml格式
 **/
 
 // 当前命令运行的目录
@@ -47,11 +52,13 @@ func getDefaultConfig() string {
 				],
 				"cmds":[
 					"npm start"
-				]
+				],
+				"debounce_ms":%d,
+				"policy":"%s"
 			}
 		]
 	}
-	`, defaultIncludes, defaultExcludesStr)
+	`, defaultIncludes, defaultExcludesStr, defaultDebounceMs, policyCoalesce)
 	return defaultConfig
 }
 
@@ -64,6 +71,25 @@ type Watcher struct {
 	Exclude    []string `json:"exclude"`
 	Cmds       []string `json:"cmds"`
 	Extensions []string `json:"extensions"`
+	// 是否遵循遍历时遇到的.gitignore和.doraignore
+	Gitignore  bool   `json:"gitignore"`
+	DebounceMs int    `json:"debounce_ms"`
+	Policy     string `json:"policy"`
+	// 执行模式，run（默认）或restart
+	Mode string `json:"mode"`
+	// 停止或重启命令时等待进程退出的时间，超时强制结束
+	KillTimeoutMs int `json:"kill_timeout_ms"`
+	// 命令执行的目录，相对配置文件所在目录，默认为运行dora watch的目录
+	Cwd string `json:"cwd"`
+	// 额外注入给命令的环境变量
+	Env map[string]string `json:"env"`
+	// 是否将cmds作为模板渲染，默认渲染，命令中需要原样的{{}}时（如docker --format '{{.Names}}'）设置为false
+	Template *bool `json:"template"`
+}
+
+// 未设置template时默认渲染
+func (w *Watcher) templateEnabled() bool {
+	return w.Template == nil || *w.Template
 }
 
 func fileExists(filename string) bool {
@@ -93,8 +119,13 @@ func getConfig(configFileName string) string {
 		}
 		defer file.Close()
 
-		defaultConfigStr := getDefaultConfig()
-		_, err = file.WriteString(defaultConfigStr)
+		// 按配置文件后缀生成对应格式的默认配置
+		defaultConfig, err := tools.JSONToConfig([]byte(getDefaultConfig()), tools.DetectConfigFormat(configFilePath, nil))
+		if err != nil {
+			fmt.Println("生成默认配置失败：", err)
+			return ""
+		}
+		_, err = file.Write(defaultConfig)
 		if err != nil {
 			fmt.Println("写入默认配置失败：", err)
 			return ""
@@ -108,16 +139,6 @@ func getConfig(configFileName string) string {
 	return string(content)
 }
 
-// 判断文件是否在排除列表中
-func isExcluded(file string, excludes []string) bool {
-	for _, exclude := range excludes {
-		if isFromDir(file, exclude) {
-			return true
-		}
-	}
-	return false
-}
-
 // 检查文件是否匹配特定的后缀
 func isValidExtension(file string, extensions []string) bool {
 	for _, ext := range extensions {
@@ -138,77 +159,58 @@ func isFromDir(file string, dir string) bool {
 	return !strings.HasPrefix(relPath, "..")
 }
 
-// 执行命令
-var lastRunTime time.Time
-
-func runCmds(cmds []string) {
-	currentTime := time.Now()
-	if currentTime.Sub(lastRunTime) < 5*time.Second {
-		// fmt.Println("频繁操作，被忽略")
-		return
-	}
-	lastRunTime = currentTime
-
-	// 暂停1000ms，等待所有文件保存成功，再运行
-	// time.Sleep(1000 * time.Millisecond)
-
-	for _, cmd := range cmds {
-		parts := strings.Fields(cmd)
-		head := parts[0]
-		// 剩余的作为参数
-		args := parts[1:]
-
-		// 如果是cd到一个目录
-		if head == "cd" {
-			// 切换到目标目录
-			targetDir := args[0]
-			if err := os.Chdir(targetDir); err != nil {
-				fmt.Printf("切换到目录 %s 失败: %v\n", targetDir, err)
-			}
-			continue
-		}
-
-		fmt.Printf("[执行命令]: %s %s\n", head, strings.Join(args, " "))
-		command := exec.Command(head, args...)
-		command.Stdout = os.Stdout
-		command.Stderr = os.Stderr
-		err := command.Run()
+// 递归添加目录监听，跳过排除和忽略的目录
+func addWatchDirs(watcher *fsnotify.Watcher, matcher *watchMatcher, root string) error {
+	return filepath.Walk(root, func(file string, fi os.FileInfo, err error) error {
 		if err != nil {
-			fmt.Printf("命令执行失败: %s, 错误: %s\n", cmd, err)
+			return err
 		}
-	}
+		if !fi.IsDir() {
+			return nil
+		}
+		// 如果是目录且不在排除列表中，则添加监听
+		if matcher.Excluded(file, true) {
+			return filepath.SkipDir
+		}
+		matcher.LoadIgnoreFiles(file)
+		if err := watcher.Add(file); err != nil {
+			return err
+		}
+		fmt.Printf("[监听目录]: %s\n", file)
+		return nil
+	})
 }
 
-// 监听文件变化
-func watchFiles(watcher *fsnotify.Watcher, w *Watcher) {
-	include := w.Include
-	exclude := w.Exclude
+// 监听文件变化，返回停止时的清理函数
+// include、exclude中的相对路径相对于配置文件所在目录baseDir
+func watchFiles(watcher *fsnotify.Watcher, w *Watcher, baseDir string) (func(), error) {
 	cmds := w.Cmds
-	extensions := w.Extensions
 
-	for _, path := range include {
+	proc := newWatchProcess(w)
+	run := func(changes []watchChange) {
+		newWatchRun(w, baseDir, newWatchContext(changes, baseDir), proc).RunAll(cmds)
+	}
+	stopProcess := proc.Close
+	switch w.Mode {
+	case modeRestart:
+		runner := newRestartRunner(w, baseDir)
+		run = func(changes []watchChange) {
+			runner.Run(newWatchContext(changes, baseDir))
+		}
+		stopProcess = runner.Close
+	case modeRun, "":
+	default:
+		fmt.Printf("未知的mode: %s，使用 %s\n", w.Mode, modeRun)
+	}
+
+	matcher := newWatchMatcher(w, baseDir)
+	for _, path := range matcher.Roots() {
 		// 递归添加目录，跳过排除路径
-		err := filepath.Walk(path, func(file string, fi os.FileInfo, err error) error {
-			if err != nil {
-				return err
-			}
-			// 如果是目录且不在排除列表中，则添加监听
-			if fi.IsDir() {
-				if !isExcluded(file, exclude) {
-					if err := watcher.Add(file); err != nil {
-						return err
-					}
-					fmt.Printf("[监听目录]: %s\n", file)
-				} else {
-					// fmt.Printf("跳过排除的目录: %s\n", file)
-				}
-			}
-			return nil
-		})
-		if err != nil {
-			log.Fatalf("添加监听失败: %s, 错误: %v", path, err)
+		if err := addWatchDirs(watcher, matcher, path); err != nil {
+			return nil, fmt.Errorf("添加监听失败: %s, 错误: %v", path, err)
 		}
 	}
+	scheduler := newWatchScheduler(w, run)
 
 	// 处理文件变化事件
 	go func() {
@@ -219,56 +221,43 @@ func watchFiles(watcher *fsnotify.Watcher, w *Watcher) {
 					return
 				}
 
-				// 检查事件来自哪个文件夹
-				for _, dir := range include {
-					if isFromDir(event.Name, dir) {
-						// fmt.Printf("文件事件来自: %s, 文件: %s\n", dir, event.Name)
-
-						// 过滤掉 exclude 列表中的文件
-						if isExcluded(event.Name, exclude) {
-							// fmt.Printf("文件被忽略: %s\n", event.Name)
-							continue
-						}
-
-						// 如果是新建文件夹，则重启监听
-						if event.Op&fsnotify.Create == fsnotify.Create {
-							// 使用 os.Stat 来检查是否是目录
-							fi, err := os.Stat(event.Name)
-							if err == nil && fi.IsDir() {
-								// fmt.Printf("新文件夹创建: %s，加入监听\n", event.Name)
-								if err := watcher.Add(event.Name); err != nil {
-									fmt.Printf("无法添加新文件夹到监听: %s\n", err)
-								}
-								continue
+				// 如果是新建文件夹，则加入监听
+				if event.Op&fsnotify.Create == fsnotify.Create {
+					// 使用 os.Stat 来检查是否是目录
+					fi, err := os.Stat(event.Name)
+					if err == nil && fi.IsDir() {
+						if matcher.inRoots(event.Name) && !matcher.Excluded(event.Name, true) {
+							if err := addWatchDirs(watcher, matcher, event.Name); err != nil {
+								fmt.Printf("无法添加新文件夹到监听: %s\n", err)
 							}
 						}
+						continue
+					}
+				}
 
-						// 如果限定了后缀，不符合的后缀直接忽略
-						if len(extensions) != 0 {
-							if !isValidExtension(event.Name, extensions) {
-								// fmt.Printf("文件后缀不符合: %s\n", event.Name)
-								continue
-							}
-						}
+				// 过滤掉不匹配include、exclude、忽略文件和后缀的文件
+				if !matcher.Match(event.Name) {
+					continue
+				}
 
-						// 处理文件变化
-						if event.Op&fsnotify.Create == fsnotify.Create {
-							fmt.Printf("[文件创建]: %s\n", event.Name)
-							runCmds(cmds)
-						} else if event.Op&fsnotify.Write == fsnotify.Write {
-							fmt.Printf("[文件修改]: %s\n", event.Name)
-							runCmds(cmds)
-						} else if event.Op&fsnotify.Remove == fsnotify.Remove {
-							fmt.Printf("[文件删除]: %s\n", event.Name)
-							runCmds(cmds)
-						} else if event.Op&fsnotify.Rename == fsnotify.Rename {
-							fmt.Printf("[文件重命名]: %s\n", event.Name)
-							runCmds(cmds)
-						} else {
-							runCmds(cmds)
-						}
-					}
+				// 处理文件变化
+				change := watchChange{File: event.Name}
+				if event.Op&fsnotify.Create == fsnotify.Create {
+					fmt.Printf("[文件创建]: %s\n", event.Name)
+					change.Op = "create"
+				} else if event.Op&fsnotify.Write == fsnotify.Write {
+					fmt.Printf("[文件修改]: %s\n", event.Name)
+					change.Op = "write"
+				} else if event.Op&fsnotify.Remove == fsnotify.Remove {
+					fmt.Printf("[文件删除]: %s\n", event.Name)
+					change.Op = "remove"
+				} else if event.Op&fsnotify.Rename == fsnotify.Rename {
+					fmt.Printf("[文件重命名]: %s\n", event.Name)
+					change.Op = "rename"
+				} else {
+					change.Op = "chmod"
 				}
+				scheduler.Trigger(change)
 
 			case err, ok := <-watcher.Errors:
 				if !ok {
@@ -280,13 +269,11 @@ func watchFiles(watcher *fsnotify.Watcher, w *Watcher) {
 	}()
 
 	// 初始化启动执行
-	// 先进入当前运行的目录
-	targetDir := currentDir
-	fmt.Println("进入目录：", targetDir)
-	if err := os.Chdir(targetDir); err != nil {
-		fmt.Printf("切换到目录 %s 失败: %v\n", targetDir, err)
-	}
-	runCmds(cmds)
+	scheduler.RunNow()
+	return func() {
+		scheduler.Stop()
+		stopProcess()
+	}, nil
 }
 
 var configFilePath string
@@ -295,37 +282,39 @@ var watchCmd = &cobra.Command{
 	Use:   "watch",
 	Short: "首次自动生成.dora.json，监听变化执行命令，可diy配置路径",
 	Run: func(cmd *cobra.Command, args []string) {
+		tools.RunSource = "watch"
 		// 获取当前命令所在目录
 		currentDir = getCurrentDir()
 
 		// 获取对应的配置文件
 		jsonStr := getConfig(configFilePath)
+		configPath := filepath.Join(currentDir, configFilePath)
+		baseDir := filepath.Dir(configPath)
 
 		// 解析配置
-		var config Config
-		err := json.Unmarshal([]byte(jsonStr), &config)
+		config, err := parseWatchConfig(configPath, []byte(jsonStr), baseDir)
 		if err != nil {
-			fmt.Println("解析 JSON 失败:", err)
+			fmt.Printf("解析配置失败:\n%s\n", err)
 			return
 		}
-		watchers := config.Watchers
 
-		// 创建 fsnotify 监听器
-		watcher, err := fsnotify.NewWatcher()
-		if err != nil {
-			fmt.Println("创建监听器失败:", err)
-			return
-		}
-		defer watcher.Close()
+		// 处理每个 watcher 的监听，每个 watcher 使用独立的 fsnotify 监听器和调度器
+		manager := newWatchManager(baseDir)
+		manager.Apply(config.Watchers)
 
-		// 处理每个 watcher 的监听
-		for _, w := range watchers {
-			watchFiles(watcher, &w)
+		// 监听配置文件，变化时只重启有变化的watcher
+		configWatcher, err := watchConfigFile(configPath, manager)
+		if err != nil {
+			fmt.Println("监听配置文件失败:", err)
+		} else {
+			defer configWatcher.Close()
 		}
 
-		// 阻止主协程退出
-		done := make(chan bool)
-		<-done
+		// 阻止主协程退出，收到退出信号时结束常驻进程
+		signals := make(chan os.Signal, 1)
+		defer tools.NotifySignals(signals, os.Interrupt, syscall.SIGTERM)()
+		<-signals
+		manager.StopAll()
 	},
 }
 
@@ -0,0 +1,108 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/haokur/dora/tools"
+)
+
+// 一次文件变化
+type watchChange struct {
+	File string
+	Op   string
+}
+
+// 文件列表，在模板中以空格分隔输出
+type fileList []string
+
+func (l fileList) String() string {
+	return strings.Join(l, " ")
+}
+
+// 传给命令的变化上下文，watcher的template不为false时cmds中可以使用{{.File}}、{{.Files}}、{{.Op}}、{{.RelFile}}、{{.RelFiles}}
+// 文件名可能含空格时使用{{quote .Files}}转义
+// 同时以DORA_CHANGED_*环境变量注入，首次运行时均为空
+type watchContext struct {
+	// 最后一次变化的文件
+	File string
+	// 上次运行以来所有变化的文件，按首次变化的顺序去重
+	Files fileList
+	// 最后一次变化的类型：create、write、remove、rename、chmod
+	Op string
+	// 相对配置文件所在目录的路径
+	RelFile  string
+	RelFiles fileList
+}
+
+func newWatchContext(changes []watchChange, baseDir string) *watchContext {
+	ctx := &watchContext{}
+	seen := map[string]bool{}
+	for _, change := range changes {
+		ctx.File = change.File
+		ctx.Op = change.Op
+		if seen[change.File] {
+			continue
+		}
+		seen[change.File] = true
+		ctx.Files = append(ctx.Files, change.File)
+		ctx.RelFiles = append(ctx.RelFiles, relPath(baseDir, change.File))
+	}
+	if ctx.File != "" {
+		ctx.RelFile = relPath(baseDir, ctx.File)
+	}
+	return ctx
+}
+
+func relPath(baseDir string, file string) string {
+	rel, err := filepath.Rel(baseDir, file)
+	if err != nil {
+		return file
+	}
+	return rel
+}
+
+// 渲染命令中的模板变量
+func (ctx *watchContext) Render(cmd string) (string, error) {
+	if !strings.Contains(cmd, "{{") {
+		return cmd, nil
+	}
+	tpl, err := template.New("cmd").Funcs(template.FuncMap{"quote": quoteArg}).Option("missingkey=error").Parse(cmd)
+	if err != nil {
+		return "", err
+	}
+	var out bytes.Buffer
+	if err := tpl.Execute(&out, ctx); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+// 模板函数，将文件转义为shell参数，如 {{quote .File}}、{{quote .Files}}
+func quoteArg(v interface{}) string {
+	switch value := v.(type) {
+	case fileList:
+		quoted := []string{}
+		for _, file := range value {
+			quoted = append(quoted, tools.ShellQuote(file))
+		}
+		return strings.Join(quoted, " ")
+	default:
+		return tools.ShellQuote(fmt.Sprint(value))
+	}
+}
+
+// 注入给命令的环境变量，多个文件以换行分隔
+func (ctx *watchContext) Environ() []string {
+	return append(os.Environ(),
+		fmt.Sprintf("DORA_CHANGED_FILE=%s", ctx.File),
+		fmt.Sprintf("DORA_CHANGED_FILES=%s", strings.Join(ctx.Files, "\n")),
+		fmt.Sprintf("DORA_CHANGED_OP=%s", ctx.Op),
+		fmt.Sprintf("DORA_CHANGED_REL_FILE=%s", ctx.RelFile),
+		fmt.Sprintf("DORA_CHANGED_REL_FILES=%s", strings.Join(ctx.RelFiles, "\n")),
+	)
+}
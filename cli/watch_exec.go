@@ -0,0 +1,178 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/haokur/dora/tools"
+)
+
+// 一次运行的命令执行环境
+// 命令通过shell执行，cd只影响本次运行后续的命令，不会改变dora进程的工作目录
+type watchRun struct {
+	dir string
+	env []string
+	ctx *watchContext
+	// 是否渲染命令模板
+	template bool
+	proc     *watchProcess
+}
+
+// watcher正在执行的命令，watcher停止时结束其进程组
+type watchProcess struct {
+	grace time.Duration
+
+	mu     sync.Mutex
+	cmd    *exec.Cmd
+	closed bool
+}
+
+func newWatchProcess(w *Watcher) *watchProcess {
+	return &watchProcess{grace: killTimeout(w)}
+}
+
+// 记录已启动的命令，已关闭时返回false
+func (p *watchProcess) track(cmd *exec.Cmd) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return false
+	}
+	p.cmd = cmd
+	return true
+}
+
+func (p *watchProcess) untrack() {
+	p.mu.Lock()
+	p.cmd = nil
+	p.mu.Unlock()
+}
+
+func (p *watchProcess) Closed() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.closed
+}
+
+// 关闭后不再执行新的命令，正在执行的命令先发SIGTERM给整个进程组，超时未退出再发SIGKILL
+func (p *watchProcess) Close() {
+	p.mu.Lock()
+	p.closed = true
+	command := p.cmd
+	p.mu.Unlock()
+
+	if command == nil {
+		return
+	}
+	fmt.Printf("[停止进程]: %d\n", command.Process.Pid)
+	tools.StopProcess(command, p.grace)
+}
+
+func newWatchRun(w *Watcher, baseDir string, ctx *watchContext, proc *watchProcess) *watchRun {
+	dir := currentDir
+	if w.Cwd != "" {
+		dir = resolvePattern(w.Cwd, baseDir)
+	}
+	env := ctx.Environ()
+	for key, value := range w.Env {
+		env = append(env, fmt.Sprintf("%s=%s", key, value))
+	}
+	return &watchRun{
+		dir:      dir,
+		env:      env,
+		ctx:      ctx,
+		template: w.templateEnabled(),
+		proc:     proc,
+	}
+}
+
+// 生成要执行的命令，单独的cd命令只切换本次运行的目录，返回nil
+func (r *watchRun) Command(cmd string) *exec.Cmd {
+	rendered := cmd
+	if r.template {
+		var err error
+		if rendered, err = r.ctx.Render(cmd); err != nil {
+			// 原样执行可能带着未替换的{{}}，跳过这条命令
+			fmt.Printf("命令模板解析失败，跳过执行: %s, 错误: %s\n", cmd, err)
+			fmt.Println("命令中需要原样的{{}}时，可以在watcher中设置 template: false")
+			return nil
+		}
+	}
+	if strings.TrimSpace(rendered) == "" {
+		return nil
+	}
+
+	// 如果是cd到一个目录
+	if targetDir, ok := parseCd(rendered); ok {
+		targetDir = tools.ExpandHomeDir(targetDir)
+		if !filepath.IsAbs(targetDir) {
+			targetDir = filepath.Join(r.dir, targetDir)
+		}
+		if fi, err := os.Stat(targetDir); err != nil || !fi.IsDir() {
+			fmt.Printf("切换到目录 %s 失败: %v\n", targetDir, err)
+			return nil
+		}
+		r.dir = targetDir
+		return nil
+	}
+
+	fmt.Printf("[执行命令]: %s\n", rendered)
+	command := tools.NewShellCommand(rendered)
+	command.Dir = r.dir
+	command.Env = r.env
+	command.Stdout = os.Stdout
+	command.Stderr = os.Stderr
+	return command
+}
+
+// 依次执行命令，每条命令执行完成再执行下一条，watcher停止后不再执行后续命令
+func (r *watchRun) RunAll(cmds []string) {
+	for _, cmd := range cmds {
+		if r.proc.Closed() {
+			return
+		}
+		command := r.Command(cmd)
+		if command == nil {
+			continue
+		}
+		start := time.Now()
+		err := tools.StartProcess(command)
+		if err == nil {
+			// 启动过程中watcher已被停止
+			if !r.proc.track(command) {
+				tools.StopProcess(command, r.proc.grace)
+			}
+			err = tools.WaitProcess(command)
+			r.proc.untrack()
+		}
+		tools.RecordRun(commandLine(command), command.Dir, start, tools.ExitCodeOf(err), "")
+		if err != nil {
+			fmt.Printf("命令执行失败: %s, 错误: %s\n", cmd, err)
+		}
+	}
+}
+
+// 通过shell执行的命令原文
+func commandLine(command *exec.Cmd) string {
+	return command.Args[len(command.Args)-1]
+}
+
+// 是否是单独的cd命令，如 cd ./web，带有&&等的交给shell处理
+func parseCd(cmd string) (string, bool) {
+	parts := strings.Fields(cmd)
+	if len(parts) == 0 || parts[0] != "cd" {
+		return "", false
+	}
+	if len(parts) == 1 {
+		return "~", true
+	}
+	if len(parts) > 2 || strings.ContainsAny(cmd, "&|;$`") {
+		return "", false
+	}
+	return strings.Trim(parts[1], `"'`), true
+}
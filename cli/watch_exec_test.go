@@ -0,0 +1,44 @@
+package cli
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+)
+
+func TestWatchRunTemplate(t *testing.T) {
+	baseDir := t.TempDir()
+	ctx := newWatchContext([]watchChange{{File: filepath.Join(baseDir, "a.go"), Op: "write"}}, baseDir)
+
+	tests := []struct {
+		name string
+		// watcher的配置，只包含template
+		config string
+		cmd    string
+		// 期望执行的命令，为空时期望跳过
+		want string
+	}{
+		{"默认渲染模板变量", `{}`, "echo {{.RelFile}} {{.Op}}", "echo a.go write"},
+		{"没有模板变量原样执行", `{}`, "go test ./...", "go test ./..."},
+		{"渲染失败时跳过", `{}`, "docker ps --format '{{.Names}}'", ""},
+		{"模板语法错误时跳过", `{}`, "echo {{.File", ""},
+		{"template为false时原样执行", `{"template":false}`, "docker ps --format '{{.Names}}'", "docker ps --format '{{.Names}}'"},
+		{"template为true时渲染", `{"template":true}`, "echo {{quote .RelFile}}", "echo a.go"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &Watcher{}
+			if err := json.Unmarshal([]byte(tt.config), w); err != nil {
+				t.Fatal(err)
+			}
+			command := newWatchRun(w, baseDir, ctx, nil).Command(tt.cmd)
+			got := ""
+			if command != nil {
+				got = command.Args[len(command.Args)-1]
+			}
+			if got != tt.want {
+				t.Errorf("Command(%q) 执行 %q，期望 %q", tt.cmd, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,270 @@
+package cli
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// 遍历目录时读取的忽略文件
+var ignoreFileNames = []string{".gitignore", ".doraignore"}
+
+// 忽略文件中的一条规则
+type ignoreRule struct {
+	pattern string
+	negate  bool
+	dirOnly bool
+}
+
+// watcher的文件匹配规则
+// include/exclude支持绝对路径、相对配置文件的路径、doublestar glob（src/**/*.ts）
+// include中以!开头的为反向规则（!**/*.test.ts）
+type watchMatcher struct {
+	roots      []string
+	includes   []string
+	negates    []string
+	excludes   []string
+	extensions []string
+	gitignore  bool
+
+	mu      sync.Mutex
+	ignores map[string][]ignoreRule
+}
+
+func newWatchMatcher(w *Watcher, baseDir string) *watchMatcher {
+	m := &watchMatcher{
+		extensions: w.Extensions,
+		gitignore:  w.Gitignore,
+		ignores:    map[string][]ignoreRule{},
+	}
+	for _, include := range w.Include {
+		if strings.HasPrefix(include, "!") {
+			m.negates = append(m.negates, resolvePattern(include[1:], baseDir))
+			continue
+		}
+		pattern := resolvePattern(include, baseDir)
+		root := globRoot(pattern)
+		if root == pattern {
+			// 普通目录，匹配目录下所有文件
+			pattern = filepath.Join(pattern, "**")
+		}
+		m.roots = append(m.roots, root)
+		m.includes = append(m.includes, pattern)
+	}
+	for _, exclude := range w.Exclude {
+		m.excludes = append(m.excludes, resolvePattern(exclude, baseDir))
+	}
+	if m.gitignore {
+		m.LoadIgnoreFiles(baseDir)
+	}
+	return m
+}
+
+// 相对路径转为相对baseDir的绝对路径
+func resolvePattern(pattern string, baseDir string) string {
+	pattern = filepath.FromSlash(pattern)
+	if !filepath.IsAbs(pattern) {
+		pattern = filepath.Join(baseDir, pattern)
+	}
+	return filepath.Clean(pattern)
+}
+
+// 是否包含glob通配符
+func hasGlobMeta(s string) bool {
+	return strings.ContainsAny(s, "*?[{")
+}
+
+// glob中不含通配符的目录部分，作为遍历的起点
+func globRoot(pattern string) string {
+	if !hasGlobMeta(pattern) {
+		return pattern
+	}
+	parts := strings.Split(pattern, string(filepath.Separator))
+	for i, part := range parts {
+		if hasGlobMeta(part) {
+			root := strings.Join(parts[:i], string(filepath.Separator))
+			if root == "" {
+				return string(filepath.Separator)
+			}
+			return root
+		}
+	}
+	return pattern
+}
+
+// 路径或其上级目录是否匹配规则，普通路径按目录前缀判断
+func matchPathOrParent(pattern string, file string) bool {
+	if !hasGlobMeta(pattern) {
+		return isFromDir(file, pattern)
+	}
+	for p := file; ; p = filepath.Dir(p) {
+		if ok, _ := doublestar.PathMatch(pattern, p); ok {
+			return true
+		}
+		if filepath.Dir(p) == p {
+			return false
+		}
+	}
+}
+
+// 需要遍历注册监听的根目录
+func (m *watchMatcher) Roots() []string {
+	return m.roots
+}
+
+// 是否在某个include根目录下
+func (m *watchMatcher) inRoots(file string) bool {
+	for _, root := range m.roots {
+		if isFromDir(file, root) {
+			return true
+		}
+	}
+	return false
+}
+
+// 路径是否被exclude或忽略文件排除
+func (m *watchMatcher) Excluded(file string, isDir bool) bool {
+	for _, exclude := range m.excludes {
+		if matchPathOrParent(exclude, file) {
+			return true
+		}
+	}
+	return m.gitignore && m.ignored(file, isDir)
+}
+
+// 文件变化是否需要触发命令
+func (m *watchMatcher) Match(file string) bool {
+	if !m.inRoots(file) || m.Excluded(file, false) {
+		return false
+	}
+
+	included := false
+	for _, include := range m.includes {
+		if ok, _ := doublestar.PathMatch(include, file); ok {
+			included = true
+			break
+		}
+	}
+	if !included {
+		return false
+	}
+	// 反向规则和exclude一样，没有通配符时为目录下的所有文件
+	for _, negate := range m.negates {
+		if matchPathOrParent(negate, file) {
+			return false
+		}
+	}
+
+	// 如果限定了后缀，不符合的后缀直接忽略
+	if len(m.extensions) != 0 && !isValidExtension(file, m.extensions) {
+		return false
+	}
+	return true
+}
+
+// 读取目录下的.gitignore和.doraignore
+func (m *watchMatcher) LoadIgnoreFiles(dir string) {
+	if !m.gitignore {
+		return
+	}
+	rules := []ignoreRule{}
+	for _, name := range ignoreFileNames {
+		rules = append(rules, readIgnoreFile(filepath.Join(dir, name))...)
+	}
+	if len(rules) == 0 {
+		return
+	}
+	m.mu.Lock()
+	m.ignores[dir] = rules
+	m.mu.Unlock()
+}
+
+// 按gitignore的规则判断路径是否被忽略，上级目录被忽略时其下所有文件都被忽略
+func (m *watchMatcher) ignored(file string, isDir bool) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for p, dir := file, isDir; ; p, dir = filepath.Dir(p), true {
+		if m.ignoredSelf(p, dir) {
+			return true
+		}
+		if filepath.Dir(p) == p {
+			return false
+		}
+	}
+}
+
+// 只判断路径本身，越靠近文件的忽略文件、越靠后的规则优先级越高
+func (m *watchMatcher) ignoredSelf(file string, isDir bool) bool {
+	ignored := false
+	dirs := []string{}
+	for d := filepath.Dir(file); ; d = filepath.Dir(d) {
+		dirs = append(dirs, d)
+		if filepath.Dir(d) == d {
+			break
+		}
+	}
+	for i := len(dirs) - 1; i >= 0; i-- {
+		rules, ok := m.ignores[dirs[i]]
+		if !ok {
+			continue
+		}
+		relPath, err := filepath.Rel(dirs[i], file)
+		if err != nil {
+			continue
+		}
+		relPath = filepath.ToSlash(relPath)
+		for _, rule := range rules {
+			if rule.dirOnly && !isDir {
+				continue
+			}
+			if ok, _ := doublestar.Match(rule.pattern, relPath); ok {
+				ignored = !rule.negate
+			}
+		}
+	}
+	return ignored
+}
+
+// 解析忽略文件，文件不存在时返回空
+func readIgnoreFile(filePath string) []ignoreRule {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	rules := []ignoreRule{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		rule := ignoreRule{}
+		if strings.HasPrefix(line, "!") {
+			rule.negate = true
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly = true
+			line = strings.TrimRight(line, "/")
+		}
+		// 含有/的规则相对忽略文件所在目录，否则匹配任意层级
+		if strings.Contains(line, "/") {
+			line = strings.TrimPrefix(line, "/")
+		} else {
+			line = "**/" + line
+		}
+		if line == "" {
+			continue
+		}
+		rule.pattern = line
+		rules = append(rules, rule)
+	}
+	return rules
+}
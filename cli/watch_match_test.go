@@ -0,0 +1,92 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeIgnoreFile(t *testing.T, dir string, name string, content string) {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWatchMatcherIgnore(t *testing.T) {
+	baseDir := t.TempDir()
+	writeIgnoreFile(t, baseDir, ".gitignore", "# 注释\n*.log\n!keep.log\n/build\ndocs/*.md\ntmp/\n")
+	writeIgnoreFile(t, filepath.Join(baseDir, "sub"), ".doraignore", "!debug.log\n/local\n")
+
+	m := newWatchMatcher(&Watcher{Include: []string{"."}, Gitignore: true}, baseDir)
+	// 遍历到子目录时才读取其中的忽略文件
+	m.LoadIgnoreFiles(filepath.Join(baseDir, "sub"))
+
+	tests := []struct {
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{"a.log", false, true},
+		{"sub/x/a.log", false, true},
+		{"keep.log", false, false},
+		{"sub/keep.log", false, false},
+		{"sub/debug.log", false, false},
+		{"other/debug.log", false, true},
+		{"build", true, true},
+		{"build/out.js", false, true},
+		{"sub/build/out.js", false, false},
+		{"sub/local/a.txt", false, true},
+		{"local/a.txt", false, false},
+		{"docs/a.md", false, true},
+		{"docs/x/a.md", false, false},
+		{"tmp/file.txt", false, true},
+		{"tmp", true, true},
+		{"tmp", false, false},
+		{"src/main.go", false, false},
+	}
+	for _, tt := range tests {
+		file := filepath.Join(baseDir, filepath.FromSlash(tt.path))
+		if got := m.Excluded(file, tt.isDir); got != tt.want {
+			t.Errorf("Excluded(%s, %v) = %v，期望 %v", tt.path, tt.isDir, got, tt.want)
+		}
+	}
+}
+
+func TestWatchMatcherInclude(t *testing.T) {
+	baseDir := t.TempDir()
+	w := &Watcher{
+		Include:    []string{"src", "config/*.json", "!**/*.test.ts", "!src/generated"},
+		Exclude:    []string{"src/vendor", "**/*.tmp"},
+		Extensions: []string{".ts", ".json"},
+	}
+	m := newWatchMatcher(w, baseDir)
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"src/a.ts", true},
+		{"src/deep/b.ts", true},
+		{"src/a.test.ts", false},
+		{"src/deep/b.test.ts", false},
+		{"src/vendor/c.ts", false},
+		{"src/generated/x.ts", false},
+		{"src/generated/deep/y.ts", false},
+		{"src/generated.ts", true},
+		{"src/a.go", false},
+		{"src/a.ts.tmp", false},
+		{"config/app.json", true},
+		{"config/nested/app.json", false},
+		{"other/a.ts", false},
+	}
+	for _, tt := range tests {
+		file := filepath.Join(baseDir, filepath.FromSlash(tt.path))
+		if got := m.Match(file); got != tt.want {
+			t.Errorf("Match(%s) = %v，期望 %v", tt.path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/haokur/dora/tools"
+	"gopkg.in/fsnotify.v1"
+)
+
+// 配置文件变化的防抖时间，单位毫秒
+const configReloadDebounceMs = 200
+
+// 解析并校验watch配置，支持json、yaml、toml，baseDir为配置文件所在目录
+func parseWatchConfig(configPath string, data []byte, baseDir string) (Config, error) {
+	var config Config
+	if errs := tools.ValidateConfig(configPath, data, watchConfigSchema, baseDir); len(errs) > 0 {
+		messages := []string{}
+		for _, err := range errs {
+			messages = append(messages, err.Error())
+		}
+		return config, errors.New(strings.Join(messages, "\n"))
+	}
+	err := tools.DecodeConfig(configPath, data, &config)
+	return config, err
+}
+
+// 运行中的watcher
+type watchHandle struct {
+	watcher *fsnotify.Watcher
+	cleanup func()
+}
+
+func (h *watchHandle) Stop() {
+	h.cleanup()
+	h.watcher.Close()
+}
+
+// 管理所有运行中的watcher，配置变化时按watcher的完整配置比较，只增删有变化的
+type watchManager struct {
+	baseDir string
+
+	mu      sync.Mutex
+	running map[string][]*watchHandle
+}
+
+func newWatchManager(baseDir string) *watchManager {
+	return &watchManager{
+		baseDir: baseDir,
+		running: map[string][]*watchHandle{},
+	}
+}
+
+// watcher配置的唯一标识
+func watcherKey(w Watcher) string {
+	data, _ := json.Marshal(w)
+	return string(data)
+}
+
+// 应用新的watcher列表，未变化的保持运行
+func (m *watchManager) Apply(watchers []Watcher) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	next := map[string][]*watchHandle{}
+	added := []Watcher{}
+	for _, w := range watchers {
+		key := watcherKey(w)
+		if handles := m.running[key]; len(handles) > 0 {
+			next[key] = append(next[key], handles[0])
+			m.running[key] = handles[1:]
+			continue
+		}
+		added = append(added, w)
+	}
+
+	// 停止已删除或有变化的watcher
+	for _, handles := range m.running {
+		for _, handle := range handles {
+			handle.Stop()
+		}
+	}
+	m.running = next
+
+	for i := range added {
+		w := added[i]
+		handle, err := m.start(&w)
+		if err != nil {
+			fmt.Println(err)
+			continue
+		}
+		key := watcherKey(w)
+		m.running[key] = append(m.running[key], handle)
+	}
+}
+
+func (m *watchManager) start(w *Watcher) (*watchHandle, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("创建监听器失败: %v", err)
+	}
+	cleanup, err := watchFiles(watcher, w, m.baseDir)
+	if err != nil {
+		watcher.Close()
+		return nil, err
+	}
+	return &watchHandle{watcher: watcher, cleanup: cleanup}, nil
+}
+
+// 停止所有watcher
+func (m *watchManager) StopAll() {
+	m.Apply(nil)
+}
+
+// 重新读取配置文件，解析失败时保留原配置
+func (m *watchManager) Reload(configPath string) {
+	content, err := os.ReadFile(configPath)
+	if err != nil {
+		fmt.Println("读取配置文件失败，保留原配置:", err)
+		return
+	}
+	config, err := parseWatchConfig(configPath, content, m.baseDir)
+	if err != nil {
+		fmt.Printf("解析配置失败，保留原配置:\n%s\n", err)
+		return
+	}
+	fmt.Println("[重新加载配置]:", configPath)
+	m.Apply(config.Watchers)
+}
+
+// 监听配置文件所在目录，兼容编辑器先删除再重命名的保存方式
+func watchConfigFile(configPath string, manager *watchManager) (*fsnotify.Watcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	if err := watcher.Add(filepath.Dir(configPath)); err != nil {
+		watcher.Close()
+		return nil, err
+	}
+
+	scheduler := newWatchScheduler(&Watcher{DebounceMs: configReloadDebounceMs}, func(changes []watchChange) {
+		manager.Reload(configPath)
+	})
+	go func() {
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					scheduler.Stop()
+					return
+				}
+				if filepath.Clean(event.Name) != configPath || event.Op&fsnotify.Chmod == fsnotify.Chmod {
+					continue
+				}
+				scheduler.Trigger(watchChange{File: event.Name})
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				fmt.Println("监听配置文件错误:", err)
+			}
+		}
+	}()
+	return watcher, nil
+}
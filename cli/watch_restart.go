@@ -0,0 +1,134 @@
+package cli
+
+import (
+	"fmt"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/haokur/dora/tools"
+)
+
+// 命令执行模式
+const (
+	// 依次执行cmds，每条命令执行完成再执行下一条
+	modeRun = "run"
+	// 最后一条命令作为常驻进程，文件变化时杀掉整个进程组再重新启动
+	modeRestart = "restart"
+)
+
+// 默认的退出等待时间，超时后发送SIGKILL，单位毫秒
+const defaultKillTimeoutMs = 3000
+
+// 重启模式下的常驻进程管理
+type restartRunner struct {
+	watcher *Watcher
+	baseDir string
+	grace   time.Duration
+	// 前置命令的执行
+	proc *watchProcess
+
+	mu     sync.Mutex
+	cmd    *exec.Cmd
+	done   chan struct{}
+	closed bool
+}
+
+// 等待进程退出的时间，默认defaultKillTimeoutMs
+func killTimeout(w *Watcher) time.Duration {
+	killTimeoutMs := w.KillTimeoutMs
+	if killTimeoutMs <= 0 {
+		killTimeoutMs = defaultKillTimeoutMs
+	}
+	return time.Duration(killTimeoutMs) * time.Millisecond
+}
+
+func newRestartRunner(w *Watcher, baseDir string) *restartRunner {
+	return &restartRunner{
+		watcher: w,
+		baseDir: baseDir,
+		grace:   killTimeout(w),
+		proc:    newWatchProcess(w),
+	}
+}
+
+// 停止上一次的常驻进程，执行前置命令后重新启动常驻进程
+func (r *restartRunner) Run(ctx *watchContext) {
+	r.Stop()
+	r.mu.Lock()
+	closed := r.closed
+	r.mu.Unlock()
+	if closed {
+		return
+	}
+	cmds := r.watcher.Cmds
+	if len(cmds) == 0 {
+		return
+	}
+
+	// 除最后一条外的命令依次执行完成
+	last := len(cmds) - 1
+	run := newWatchRun(r.watcher, r.baseDir, ctx, r.proc)
+	run.RunAll(cmds[:last])
+
+	command := run.Command(cmds[last])
+	if command == nil {
+		return
+	}
+	start := time.Now()
+	if err := tools.StartProcess(command); err != nil {
+		fmt.Printf("命令启动失败: %s, 错误: %s\n", cmds[last], err)
+		return
+	}
+
+	done := make(chan struct{})
+	go func() {
+		err := tools.WaitProcess(command)
+		tools.RecordRun(commandLine(command), command.Dir, start, tools.ExitCodeOf(err), "")
+		if err != nil {
+			fmt.Printf("[进程退出]: %s, %s\n", cmds[last], err)
+		}
+		close(done)
+	}()
+
+	r.mu.Lock()
+	r.cmd = command
+	r.done = done
+	closed = r.closed
+	r.mu.Unlock()
+
+	// 启动过程中watcher已被关闭
+	if closed {
+		r.Stop()
+	}
+}
+
+// 关闭后不再启动新的进程
+func (r *restartRunner) Close() {
+	r.mu.Lock()
+	r.closed = true
+	r.mu.Unlock()
+	r.proc.Close()
+	r.Stop()
+}
+
+// 停止常驻进程：先发SIGTERM给整个进程组，超时未退出再发SIGKILL
+func (r *restartRunner) Stop() {
+	r.mu.Lock()
+	command, done := r.cmd, r.done
+	r.cmd, r.done = nil, nil
+	r.mu.Unlock()
+
+	if command == nil {
+		return
+	}
+	select {
+	case <-done:
+		return
+	default:
+	}
+
+	fmt.Printf("[停止进程]: %d\n", command.Process.Pid)
+	tools.StopProcess(command, r.grace)
+	<-done
+}
@@ -0,0 +1,108 @@
+//go:build !windows
+
+package cli
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeScript(t *testing.T, dir string, name string, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+content+"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// 进程是否还在运行，僵尸进程视为已结束
+func processAlive(pid int) bool {
+	out, err := exec.Command("ps", "-o", "stat=", "-p", strconv.Itoa(pid)).Output()
+	state := strings.TrimSpace(string(out))
+	return err == nil && state != "" && !strings.HasPrefix(state, "Z")
+}
+
+// 等待常驻进程写入子进程的pid
+func readPidFile(t *testing.T, path string) int {
+	t.Helper()
+	for i := 0; i < 100; i++ {
+		if data, err := os.ReadFile(path); err == nil && strings.HasSuffix(string(data), "\n") {
+			pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			return pid
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatalf("常驻进程未启动")
+	return 0
+}
+
+func TestRestartRunnerStop(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		// 至少、至多需要的停止时间
+		minStop time.Duration
+		maxStop time.Duration
+	}{
+		{"收到SIGTERM后退出", "sleep 30 &\necho $! > \"$1\"\nwait", 0, 150 * time.Millisecond},
+		{"忽略SIGTERM时超时后强制结束", "trap '' TERM\nsleep 30 &\necho $! > \"$1\"\nwait", 300 * time.Millisecond, 2 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			script := writeScript(t, dir, "serve.sh", tt.script)
+			pidFile := filepath.Join(dir, "child.pid")
+			r := newRestartRunner(&Watcher{Cmds: []string{"sh " + script + " " + pidFile}, KillTimeoutMs: 300}, dir)
+			r.Run(newWatchContext(nil, dir))
+			child := readPidFile(t, pidFile)
+
+			start := time.Now()
+			r.Stop()
+			elapsed := time.Since(start)
+			if elapsed < tt.minStop || elapsed > tt.maxStop {
+				t.Errorf("停止用时 %s，期望在 %s 到 %s 之间", elapsed, tt.minStop, tt.maxStop)
+			}
+			// 整个进程组都被结束
+			if processAlive(child) {
+				t.Errorf("子进程 %d 未被结束", child)
+			}
+		})
+	}
+}
+
+func TestRestartRunnerRestart(t *testing.T) {
+	dir := t.TempDir()
+	counter := filepath.Join(dir, "count")
+	prepare := writeScript(t, dir, "prepare.sh", "echo x >> \"$1\"")
+	serve := writeScript(t, dir, "serve.sh", "sleep 30 &\necho $! > \"$1\"\nwait")
+	pidFile := filepath.Join(dir, "child.pid")
+	r := newRestartRunner(&Watcher{Cmds: []string{"sh " + prepare + " " + counter, "sh " + serve + " " + pidFile}}, dir)
+	defer r.Stop()
+
+	pids := []int{}
+	for i := 0; i < 3; i++ {
+		os.Remove(pidFile)
+		r.Run(newWatchContext(nil, dir))
+		pids = append(pids, readPidFile(t, pidFile))
+	}
+
+	// 每次重启前执行前置命令，只保留最后一次启动的常驻进程
+	data, _ := os.ReadFile(counter)
+	if got := strings.Count(string(data), "x"); got != 3 {
+		t.Errorf("前置命令执行了 %d 次，期望 3 次", got)
+	}
+	for i, pid := range pids {
+		if alive := processAlive(pid); alive != (i == len(pids)-1) {
+			t.Errorf("第 %d 次启动的进程运行状态为 %v", i+1, alive)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package cli
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// 默认防抖时间，单位毫秒
+const defaultDebounceMs = 500
+
+// 命令运行中又收到文件变化时的处理策略
+const (
+	// 合并：运行期间的所有变化合并为一次，运行结束后再执行一次
+	policyCoalesce = "coalesce"
+	// 排队：运行期间每一批变化都排队，运行结束后依次执行
+	policyQueue = "queue"
+)
+
+// 每个watcher独立的调度器
+// 1. 防抖：debounce时间内的连续变化只触发一次
+// 2. 尾触发：以最后一次变化为准计时，最后一次保存一定会执行
+// 3. 运行中的变化按policy合并或排队
+// 4. 每次运行带上上次运行以来的所有变化
+type watchScheduler struct {
+	debounce time.Duration
+	policy   string
+	run      func(changes []watchChange)
+
+	mu         sync.Mutex
+	timer      *time.Timer
+	running    bool
+	stopped    bool
+	collecting []watchChange
+	pending    [][]watchChange
+}
+
+func newWatchScheduler(w *Watcher, run func(changes []watchChange)) *watchScheduler {
+	debounceMs := w.DebounceMs
+	if debounceMs <= 0 {
+		debounceMs = defaultDebounceMs
+	}
+
+	policy := w.Policy
+	switch policy {
+	case policyCoalesce, policyQueue:
+	case "":
+		policy = policyCoalesce
+	default:
+		fmt.Printf("未知的policy: %s，使用 %s\n", policy, policyCoalesce)
+		policy = policyCoalesce
+	}
+
+	return &watchScheduler{
+		debounce: time.Duration(debounceMs) * time.Millisecond,
+		policy:   policy,
+		run:      run,
+	}
+}
+
+// 收到文件变化，记录变化并重新开始防抖计时
+func (s *watchScheduler) Trigger(change watchChange) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stopped {
+		return
+	}
+	s.collecting = append(s.collecting, change)
+	if s.timer != nil {
+		s.timer.Stop()
+	}
+	s.timer = time.AfterFunc(s.debounce, s.fire)
+}
+
+// 跳过防抖立即执行，用于启动时的首次运行
+func (s *watchScheduler) RunNow() {
+	go s.fire()
+}
+
+// 停止调度，之后的变化不再执行，已在运行的命令由watcher的清理函数结束
+func (s *watchScheduler) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stopped = true
+	s.pending = nil
+	if s.timer != nil {
+		s.timer.Stop()
+	}
+}
+
+// 防抖结束，执行命令或记录待执行
+func (s *watchScheduler) fire() {
+	s.mu.Lock()
+	if s.stopped {
+		s.mu.Unlock()
+		return
+	}
+	changes := s.collecting
+	s.collecting = nil
+	if s.running {
+		if s.policy == policyQueue || len(s.pending) == 0 {
+			s.pending = append(s.pending, changes)
+		} else {
+			s.pending[0] = append(s.pending[0], changes...)
+		}
+		s.mu.Unlock()
+		return
+	}
+	s.running = true
+	s.mu.Unlock()
+
+	for {
+		s.run(changes)
+
+		s.mu.Lock()
+		if s.stopped || len(s.pending) == 0 {
+			s.running = false
+			s.mu.Unlock()
+			return
+		}
+		changes = s.pending[0]
+		s.pending = s.pending[1:]
+		s.mu.Unlock()
+	}
+}
@@ -0,0 +1,67 @@
+package cli
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// 测试使用的防抖时间，单位毫秒
+const testDebounceMs = 60
+
+func TestWatchScheduler(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy string
+		// 每次变化距开始的时间，单位毫秒
+		triggers []int
+		// 每次运行的耗时，单位毫秒
+		runMs int
+		want  int
+	}{
+		{"防抖时间内的连续变化只执行一次", "", []int{0, 10, 20, 30}, 0, 1},
+		{"间隔超过防抖时间分别执行", "", []int{0, 200}, 0, 2},
+		{"持续变化时以最后一次为准", "", []int{0, 15, 30, 45, 60, 75, 90, 105, 120}, 0, 1},
+		{"运行中的多批变化合并为一次", policyCoalesce, []int{0, 150, 250, 350}, 400, 2},
+		{"运行中的每批变化排队执行", policyQueue, []int{0, 150, 250, 350}, 400, 4},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var mu sync.Mutex
+			runs := []time.Time{}
+			changes := 0
+			s := newWatchScheduler(&Watcher{DebounceMs: testDebounceMs, Policy: tt.policy}, func(batch []watchChange) {
+				mu.Lock()
+				runs = append(runs, time.Now())
+				changes += len(batch)
+				mu.Unlock()
+				time.Sleep(time.Duration(tt.runMs) * time.Millisecond)
+			})
+
+			start := time.Now()
+			var last time.Time
+			for _, at := range tt.triggers {
+				time.Sleep(time.Until(start.Add(time.Duration(at) * time.Millisecond)))
+				last = time.Now()
+				s.Trigger(watchChange{File: "a.go", Op: "write"})
+			}
+			time.Sleep(time.Duration(tt.runMs*tt.want+testDebounceMs)*time.Millisecond + 200*time.Millisecond)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if len(runs) != tt.want {
+				t.Fatalf("执行了 %d 次，期望 %d 次", len(runs), tt.want)
+			}
+			// 每次变化都会带给某一次运行
+			if changes != len(tt.triggers) {
+				t.Errorf("运行收到 %d 个变化，期望 %d 个", changes, len(tt.triggers))
+			}
+			// 尾触发：最后一次执行在最后一次变化的防抖时间之后
+			if tt.runMs == 0 && runs[len(runs)-1].Before(last.Add(testDebounceMs*time.Millisecond)) {
+				t.Errorf("最后一次执行早于最后一次变化后的防抖时间")
+			}
+		})
+	}
+}
@@ -0,0 +1,416 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/haokur/dora/tools"
+)
+
+// 步骤状态
+const (
+	stepSuccess = "成功"
+	stepFailed  = "失败"
+	stepTimeout = "超时"
+	stepSkipped = "跳过"
+)
+
+// 重试的默认间隔，每次重试翻倍
+const defaultRetryDelayMs = 1000
+
+// 引用之前步骤的结果，如 {{steps.build.output}}、{{steps.build.exit_code}}
+// 替换时转义为shell中的单个参数，不需要再加引号
+var stepRefPattern = regexp.MustCompile(`\{\{\s*steps\.([A-Za-z0-9_-]+)\.(output|exit_code|status)\s*\}\}`)
+
+// 一个步骤的执行结果
+type stepResult struct {
+	name     string
+	status   string
+	exitCode int
+	// 命令的原始输出，展示和记录时需要隐藏secrets
+	output   string
+	secrets  []string
+	duration time.Duration
+	attempts int
+}
+
+// 按顺序执行commands的children
+type workflow struct {
+	results []*stepResult
+	byId    map[string]*stepResult
+	failed  bool
+	// 取消时结束正在执行的命令，不再执行之后的步骤
+	ctx context.Context
+	// 命令的输出位置
+	stdout io.Writer
+	stderr io.Writer
+	// 并行执行时多个命令同时输出，不使用伪终端
+	pty bool
+	// cd步骤切换到的目录，为空时使用dora的工作目录
+	dir string
+	// 已经用到的密钥值，之前步骤的输出可能包含，输出和记录命令时隐藏
+	secrets []string
+	// 命令中的 {{参数}} 已经在执行前替换，执行步骤时不再替换
+	expanded bool
+}
+
+func newWorkflow() *workflow {
+	return &workflow{
+		byId:   map[string]*stepResult{},
+		ctx:    context.Background(),
+		stdout: commandStdout(),
+		stderr: os.Stderr,
+		pty:    true,
+	}
+}
+
+// 嵌套的分组使用同样的输出和目录
+func (w *workflow) child() *workflow {
+	return &workflow{
+		byId:     map[string]*stepResult{},
+		ctx:      w.ctx,
+		stdout:   w.stdout,
+		stderr:   w.stderr,
+		pty:      w.pty,
+		dir:      w.dir,
+		secrets:  append([]string{}, w.secrets...),
+		expanded: w.expanded,
+	}
+}
+
+// 步骤的展示名称
+func stepName(step cmdJsonItem) string {
+	for _, name := range []string{step.Label, step.Id} {
+		if name != "" {
+			return name
+		}
+	}
+	return step.Value
+}
+
+func (w *workflow) Run(steps []cmdJsonItem) error {
+	for _, step := range steps {
+		result := &stepResult{name: stepName(step), exitCode: -1}
+		w.results = append(w.results, result)
+
+		if w.ctx.Err() != nil {
+			result.status = stepCanceled
+			w.failed = true
+			continue
+		}
+		run, err := w.shouldRun(step)
+		if err != nil {
+			fmt.Fprintf(w.stdout, "步骤 %s 的when条件错误: %v\n", result.name, err)
+		}
+		if run {
+			w.runStep(step, result)
+			if result.status != stepSuccess && !step.ContinueOnError {
+				w.failed = true
+			}
+		} else {
+			result.status = stepSkipped
+		}
+		if step.Id != "" {
+			w.byId[step.Id] = result
+		}
+	}
+	if !cmdJsonOutput {
+		w.printSummary()
+	}
+	if w.failed {
+		return fmt.Errorf("工作流执行失败")
+	}
+	return nil
+}
+
+// 执行嵌套的分组，parallel为true时children同时执行，否则按步骤执行，分组中的cd对之后的步骤有效
+func (w *workflow) runGroup(step cmdJsonItem) error {
+	if step.Parallel {
+		_, err := runParallel(w, step.Children, cmdParallel, step.FailFast)
+		return err
+	}
+	sub := w.child()
+	err := sub.Run(step.Children)
+	w.dir = sub.dir
+	return err
+}
+
+// 执行一个步骤，失败时按配置重试
+func (w *workflow) runStep(step cmdJsonItem, result *stepResult) {
+	// 嵌套的分组作为一个步骤
+	if len(step.Children) > 0 {
+		start := time.Now()
+		result.attempts = 1
+		result.status, result.exitCode = stepSuccess, 0
+		if err := w.runGroup(step); err != nil {
+			fmt.Fprintln(w.stdout, err)
+			result.status, result.exitCode = stepFailed, -1
+			if w.ctx.Err() != nil {
+				result.status = stepCanceled
+			}
+		}
+		result.duration = time.Since(start)
+		return
+	}
+
+	command := step.Value
+	if !w.expanded {
+		var err error
+		command, err = expandCommand(command)
+		if err != nil {
+			fmt.Fprintln(w.stdout, err)
+			result.status = stepFailed
+			return
+		}
+	}
+	command = w.renderStepRefs(command)
+
+	delay := time.Duration(step.RetryDelayMs) * time.Millisecond
+	if delay <= 0 {
+		delay = defaultRetryDelayMs * time.Millisecond
+	}
+	start := time.Now()
+	for attempt := 0; attempt <= step.Retry; attempt++ {
+		if attempt > 0 {
+			fmt.Fprintf(w.stdout, "步骤 %s 第 %d 次重试，%v 后开始\n", result.name, attempt, delay)
+			select {
+			case <-time.After(delay):
+			case <-w.ctx.Done():
+			}
+			delay *= 2
+		}
+		if w.ctx.Err() != nil {
+			result.status = stepCanceled
+			break
+		}
+		result.attempts = attempt + 1
+		result.status, result.exitCode, result.output = w.runStepCommand(command, step)
+		result.secrets = w.secrets
+		if result.status == stepSuccess || result.status == stepCanceled {
+			break
+		}
+	}
+	result.duration = time.Since(start)
+}
+
+// 伪终端的换行为\r\n，统一为\n
+// 保留原始的输出，供之后的步骤引用
+func capturedOutput(output string) string {
+	output = strings.ReplaceAll(output, "\r\n", "\n")
+	return strings.TrimRight(output, "\n")
+}
+
+// cd步骤切换之后步骤的目录，~为用户目录，目录不存在时返回错误
+// 只改变本次执行的目录，并行的命令互不影响
+func (w *workflow) changeDir(target string) error {
+	target = tools.ExpandHomeDir(target)
+	if !filepath.IsAbs(target) {
+		base := w.dir
+		if base == "" {
+			base = tools.GetWorkDir()
+		}
+		target = filepath.Join(base, target)
+	}
+	fi, err := os.Stat(target)
+	if err != nil {
+		return fmt.Errorf("切换到目录 %s 失败: %w", target, err)
+	}
+	if !fi.IsDir() {
+		return fmt.Errorf("切换到目录 %s 失败: 不是目录", target)
+	}
+	w.dir = target
+	return nil
+}
+
+// 启动命令，不并行时在伪终端中执行
+func (w *workflow) start(c *exec.Cmd, stdout io.Writer) (func() error, error) {
+	if w.pty {
+		return tools.StartWithPty(c, stdout)
+	}
+	c.Stdout = stdout
+	c.Stderr = w.stderr
+	if err := tools.StartProcess(c); err != nil {
+		return nil, err
+	}
+	return func() error { return tools.WaitProcess(c) }, nil
+}
+
+// 执行命令，设置了id时记录输出，设置了timeout_ms时超时结束整个进程组，取消时结束命令
+func (w *workflow) runStepCommand(command string, step cmdJsonItem) (status string, exitCode int, output string) {
+	log.Println("执行命令：", tools.MaskSecrets(command, w.secrets))
+	resolved, secrets, err := tools.ResolveSecrets(command)
+	if err != nil {
+		fmt.Fprintln(w.stdout, err)
+		return stepFailed, -1, ""
+	}
+	w.secrets = append(w.secrets, secrets...)
+	start := time.Now()
+	record := func(exitCode int, output string) {
+		tools.RecordRun(tools.MaskSecrets(command, w.secrets), w.dir, start, exitCode, tools.MaskSecrets(output, w.secrets))
+	}
+	// 单独的cd切换之后步骤的目录，目录不存在时步骤失败
+	if target, ok := parseCd(resolved); ok {
+		if err := w.changeDir(target); err != nil {
+			fmt.Fprintln(w.stdout, tools.MaskSecrets(err.Error(), w.secrets))
+			record(1, "")
+			return stepFailed, 1, ""
+		}
+		record(0, "")
+		return stepSuccess, 0, ""
+	}
+
+	var captured syncBuffer
+	c := tools.NewShellCommand(resolved)
+	c.Dir = w.dir
+	stdout := w.stdout
+	if step.Id != "" {
+		stdout = io.MultiWriter(w.stdout, &captured)
+	}
+	defer func() {
+		record(exitCode, output)
+	}()
+	wait, err := w.start(c, stdout)
+	if err != nil {
+		fmt.Fprintln(w.stdout, "执行失败", err)
+		return stepFailed, -1, ""
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- wait() }()
+	var timeout <-chan time.Time
+	if step.TimeoutMs > 0 {
+		timer := time.NewTimer(time.Duration(step.TimeoutMs) * time.Millisecond)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case err := <-done:
+		output = capturedOutput(captured.String())
+		if err == nil {
+			return stepSuccess, 0, output
+		}
+		return stepFailed, tools.ExitCodeOf(err), output
+	case <-timeout:
+		fmt.Fprintf(w.stdout, "执行超过 %dms，结束进程\n", step.TimeoutMs)
+		tools.StopProcess(c, defaultKillTimeoutMs*time.Millisecond)
+		<-done
+		return stepTimeout, -1, capturedOutput(captured.String())
+	case <-w.ctx.Done():
+		tools.StopProcess(c, defaultKillTimeoutMs*time.Millisecond)
+		<-done
+		return stepCanceled, -1, capturedOutput(captured.String())
+	}
+}
+
+// 替换命令中对之前步骤结果的引用，输出和状态转义后作为单个参数，避免其中的内容作为命令执行
+func (w *workflow) renderStepRefs(command string) string {
+	return stepRefPattern.ReplaceAllStringFunc(command, func(ref string) string {
+		match := stepRefPattern.FindStringSubmatch(ref)
+		value, ok := w.stepRefValue(match[1], match[2])
+		if !ok {
+			return ""
+		}
+		if match[2] == "exit_code" {
+			return value
+		}
+		return tools.ShellQuote(value)
+	})
+}
+
+// 之前步骤结果的原始值，步骤不存在或还未执行时返回false
+func (w *workflow) stepRefValue(id string, field string) (string, bool) {
+	result, ok := w.byId[id]
+	if !ok {
+		return "", false
+	}
+	switch field {
+	case "output":
+		return result.output, true
+	case "exit_code":
+		return strconv.Itoa(result.exitCode), true
+	default:
+		return result.status, true
+	}
+}
+
+// 判断步骤是否执行
+// when为空或success：之前的步骤都成功时执行；failure：之前有步骤失败时执行；always：总是执行
+// 也可以比较之前步骤的结果，如 steps.build.exit_code == 0、steps.test.output contains "ok"
+func (w *workflow) shouldRun(step cmdJsonItem) (bool, error) {
+	when := strings.TrimSpace(step.When)
+	switch when {
+	case "", "success":
+		return !w.failed, nil
+	case "failure":
+		return w.failed, nil
+	case "always":
+		return true, nil
+	}
+
+	for _, op := range []string{"==", "!=", " contains "} {
+		left, right, ok := strings.Cut(when, op)
+		if !ok {
+			continue
+		}
+		value, err := w.stepValue(strings.TrimSpace(left))
+		if err != nil {
+			return false, err
+		}
+		right = strings.TrimSpace(right)
+		if unquoted, err := strconv.Unquote(right); err == nil {
+			right = unquoted
+		}
+		switch op {
+		case "==":
+			return value == right, nil
+		case "!=":
+			return value != right, nil
+		default:
+			return strings.Contains(value, right), nil
+		}
+	}
+	return false, fmt.Errorf("无法识别 %s", when)
+}
+
+// 读取 steps.ID.output 这类引用的值
+func (w *workflow) stepValue(ref string) (string, error) {
+	match := stepRefPattern.FindStringSubmatch("{{" + ref + "}}")
+	if match == nil || match[0] != "{{"+ref+"}}" {
+		return "", fmt.Errorf("无法识别 %s，应为 steps.ID.output、steps.ID.exit_code 或 steps.ID.status", ref)
+	}
+	value, ok := w.stepRefValue(match[1], match[2])
+	if !ok {
+		return "", fmt.Errorf("步骤 %s 不存在或还未执行", match[1])
+	}
+	return value, nil
+}
+
+// 输出每个步骤的状态和耗时
+func (w *workflow) printSummary() {
+	fmt.Fprintln(w.stdout)
+	writer := tabwriter.NewWriter(w.stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "步骤\t状态\t退出码\t耗时\t执行次数")
+	for _, result := range w.results {
+		exitCode := "-"
+		if result.exitCode >= 0 {
+			exitCode = strconv.Itoa(result.exitCode)
+		}
+		duration := "-"
+		if result.status != stepSkipped {
+			duration = result.duration.Round(time.Millisecond).String()
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%d\n", result.name, result.status, exitCode, duration, result.attempts)
+	}
+	writer.Flush()
+}
@@ -0,0 +1,108 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/haokur/dora/tools"
+)
+
+// 已执行build和test两个步骤的工作流
+func newTestWorkflow(failed bool) *workflow {
+	w := newWorkflow()
+	w.byId["build"] = &stepResult{status: stepSuccess, exitCode: 0, output: "build ok"}
+	w.byId["test"] = &stepResult{status: stepFailed, exitCode: 2, output: "1 failed"}
+	w.failed = failed
+	return w
+}
+
+func TestWorkflowShouldRun(t *testing.T) {
+	tests := []struct {
+		name    string
+		when    string
+		failed  bool
+		want    bool
+		wantErr bool
+	}{
+		{"默认在之前都成功时执行", "", false, true, false},
+		{"默认在之前失败时跳过", "", true, false, false},
+		{"success", "success", true, false, false},
+		{"failure在失败时执行", "failure", true, true, false},
+		{"failure在成功时跳过", " failure ", false, false, false},
+		{"always", "always", true, true, false},
+		{"退出码相等", "steps.build.exit_code == 0", false, true, false},
+		{"退出码不相等", "steps.test.exit_code != 0", true, true, false},
+		{"状态比较带引号", `steps.test.status == "失败"`, true, true, false},
+		{"输出包含", `steps.build.output contains "ok"`, false, true, false},
+		{"输出不包含", `steps.test.output contains "ok"`, false, false, false},
+		{"步骤不存在", "steps.deploy.exit_code == 0", false, false, true},
+		{"字段不支持", "steps.build.duration == 0", false, false, true},
+		{"无法识别的条件", "sometimes", false, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newTestWorkflow(tt.failed).shouldRun(cmdJsonItem{When: tt.when})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("shouldRun(%q) 错误为 %v，期望错误 %v", tt.when, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("shouldRun(%q) = %v，期望 %v", tt.when, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWorkflowRenderStepRefs(t *testing.T) {
+	tests := []struct {
+		command string
+		want    string
+	}{
+		{"echo {{steps.build.exit_code}}", "echo 0"},
+		{"echo {{ steps.test.exit_code }} {{steps.test.status}}", "echo 2 失败"},
+		{"echo {{steps.build.output}}", "echo " + tools.ShellQuote("build ok")},
+		{"echo {{steps.out.output}}", "echo " + tools.ShellQuote("$(touch x); echo 'a'")},
+		{"echo {{steps.deploy.output}}", "echo "},
+		{"echo {{branch}}", "echo {{branch}}"},
+	}
+	w := newTestWorkflow(false)
+	// 输出中的shell语法不会被执行
+	w.byId["out"] = &stepResult{status: stepSuccess, output: "$(touch x); echo 'a'"}
+	for _, tt := range tests {
+		if got := w.renderStepRefs(tt.command); got != tt.want {
+			t.Errorf("renderStepRefs(%q) = %q，期望 %q", tt.command, got, tt.want)
+		}
+	}
+}
+
+func TestWorkflowChangeDir(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	base := t.TempDir()
+	for _, dir := range []string{filepath.Join(home, "src"), filepath.Join(base, "a~b"), filepath.Join(base, "~x")} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		target  string
+		want    string
+		wantErr bool
+	}{
+		{"~", home, false},
+		{"~/src", filepath.Join(home, "src"), false},
+		{"a~b", filepath.Join(base, "a~b"), false},
+		{"~x", filepath.Join(base, "~x"), false},
+		{"missing", base, true},
+	}
+	for _, tt := range tests {
+		w := newWorkflow()
+		w.dir = base
+		err := w.changeDir(tt.target)
+		if (err != nil) != tt.wantErr || w.dir != tt.want {
+			t.Errorf("changeDir(%q) 切换到 %s，错误 %v，期望 %s", tt.target, w.dir, err, tt.want)
+		}
+	}
+}
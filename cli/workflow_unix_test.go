@@ -0,0 +1,206 @@
+//go:build !windows
+
+package cli
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/haokur/dora/tools"
+)
+
+func TestWorkflowRun(t *testing.T) {
+	tests := []struct {
+		name  string
+		steps func(dir string) []cmdJsonItem
+		// 每个步骤的状态、退出码和执行次数
+		wantStatus   []string
+		wantExitCode []int
+		wantAttempts []int
+		wantErr      bool
+	}{
+		{
+			name: "失败后跳过后续步骤，failure和always仍执行",
+			steps: func(dir string) []cmdJsonItem {
+				return []cmdJsonItem{
+					{Value: "exit 3"},
+					{Value: "true"},
+					{Value: "true", When: "failure"},
+					{Value: "true", When: "always"},
+				}
+			},
+			wantStatus:   []string{stepFailed, stepSkipped, stepSuccess, stepSuccess},
+			wantExitCode: []int{3, -1, 0, 0},
+			wantAttempts: []int{1, 0, 1, 1},
+			wantErr:      true,
+		},
+		{
+			name: "continue_on_error的失败不影响后续步骤",
+			steps: func(dir string) []cmdJsonItem {
+				return []cmdJsonItem{
+					{Value: "false", ContinueOnError: true},
+					{Value: "true"},
+				}
+			},
+			wantStatus:   []string{stepFailed, stepSuccess},
+			wantExitCode: []int{1, 0},
+			wantAttempts: []int{1, 1},
+		},
+		{
+			name: "重试直到成功",
+			steps: func(dir string) []cmdJsonItem {
+				counter := filepath.Join(dir, "count")
+				return []cmdJsonItem{
+					{Value: "echo x >> " + counter + " && [ $(wc -l < " + counter + ") -ge 3 ]", Retry: 3, RetryDelayMs: 10},
+				}
+			},
+			wantStatus:   []string{stepSuccess},
+			wantExitCode: []int{0},
+			wantAttempts: []int{3},
+		},
+		{
+			name: "重试次数用完仍失败",
+			steps: func(dir string) []cmdJsonItem {
+				return []cmdJsonItem{{Value: "exit 4", Retry: 2, RetryDelayMs: 10}}
+			},
+			wantStatus:   []string{stepFailed},
+			wantExitCode: []int{4},
+			wantAttempts: []int{3},
+			wantErr:      true,
+		},
+		{
+			name: "超时结束进程",
+			steps: func(dir string) []cmdJsonItem {
+				return []cmdJsonItem{{Value: "sleep 30", TimeoutMs: 100}}
+			},
+			wantStatus:   []string{stepTimeout},
+			wantExitCode: []int{-1},
+			wantAttempts: []int{1},
+			wantErr:      true,
+		},
+		{
+			name: "引用之前步骤的输出和退出码",
+			steps: func(dir string) []cmdJsonItem {
+				return []cmdJsonItem{
+					{Id: "build", Value: "echo done"},
+					{Value: `[ {{steps.build.output}} = done ] && exit {{steps.build.exit_code}}`},
+					{Value: "exit 5", When: `steps.build.output contains "done"`},
+				}
+			},
+			wantStatus:   []string{stepSuccess, stepSuccess, stepFailed},
+			wantExitCode: []int{0, 0, 5},
+			wantAttempts: []int{1, 1, 1},
+			wantErr:      true,
+		},
+		{
+			name: "输出作为单个参数，其中的shell语法不执行",
+			steps: func(dir string) []cmdJsonItem {
+				injected := filepath.Join(dir, "injected")
+				output := "$(touch " + injected + "); echo a b"
+				return []cmdJsonItem{
+					{Id: "out", Value: "printf '%s' '" + output + "'"},
+					{Value: "[ {{steps.out.output}} = '" + output + "' ] && [ ! -e " + injected + " ]"},
+				}
+			},
+			wantStatus:   []string{stepSuccess, stepSuccess},
+			wantExitCode: []int{0, 0},
+			wantAttempts: []int{1, 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("HOME", t.TempDir())
+			w := newWorkflow()
+			start := time.Now()
+			err := w.Run(tt.steps(t.TempDir()))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Run() 错误为 %v，期望错误 %v", err, tt.wantErr)
+			}
+			if time.Since(start) > 5*time.Second {
+				t.Errorf("执行用时过长: %s", time.Since(start))
+			}
+
+			status, exitCodes, attempts := []string{}, []int{}, []int{}
+			for _, result := range w.results {
+				status = append(status, result.status)
+				exitCodes = append(exitCodes, result.exitCode)
+				attempts = append(attempts, result.attempts)
+			}
+			if !reflect.DeepEqual(status, tt.wantStatus) {
+				t.Errorf("步骤状态为 %v，期望 %v", status, tt.wantStatus)
+			}
+			if !reflect.DeepEqual(exitCodes, tt.wantExitCode) {
+				t.Errorf("退出码为 %v，期望 %v", exitCodes, tt.wantExitCode)
+			}
+			if !reflect.DeepEqual(attempts, tt.wantAttempts) {
+				t.Errorf("执行次数为 %v，期望 %v", attempts, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestWorkflowSecretOutput(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	if err := tools.SaveSecrets(map[string]string{"TOKEN": "s3cr3t"}); err != nil {
+		t.Fatal(err)
+	}
+	w := newWorkflow()
+	err := w.Run([]cmdJsonItem{
+		{Id: "login", Value: "echo ${secret:TOKEN}"},
+		// 之后的步骤引用的是原始的输出
+		{Value: "[ {{steps.login.output}} = s3cr3t ]"},
+	})
+	if err != nil {
+		t.Fatalf("Run() 错误为 %v", err)
+	}
+	result := w.byId["login"]
+	if result.output != "s3cr3t" {
+		t.Errorf("记录的输出为 %q，期望原始的输出", result.output)
+	}
+	// 展示和记录时隐藏
+	if got := tools.MaskSecrets(result.output, result.secrets); got != "******" {
+		t.Errorf("隐藏密钥后为 %q", got)
+	}
+	records, _ := tools.ReadRunRecords()
+	if len(records) != 2 {
+		t.Errorf("执行记录有 %d 条，期望 2 条", len(records))
+	}
+	for _, record := range records {
+		if strings.Contains(record.Command+record.Output, "s3cr3t") {
+			t.Errorf("执行记录中包含密钥: %+v", record)
+		}
+	}
+}
+
+func TestRunCmdItemExpandOnce(t *testing.T) {
+	// 参数的值中包含 {{}}，再次替换时会被当作缺少的参数
+	cmdHeadless = true
+	cmdRunParams = map[string]string{"msg": "{{literal}}"}
+	t.Cleanup(func() {
+		cmdHeadless = false
+		cmdRunParams = nil
+	})
+	check := `[ {{msg}} = "$(printf '{{%s}}' literal)" ]`
+
+	tests := []struct {
+		name string
+		item cmdJsonItem
+	}{
+		{"单条命令", cmdJsonItem{Value: check}},
+		{"按步骤执行", cmdJsonItem{Children: []cmdJsonItem{{Value: check}}}},
+		{"并行执行", cmdJsonItem{Parallel: true, Children: []cmdJsonItem{{Value: check}, {Value: check}}}},
+		{"步骤中嵌套并行分组", cmdJsonItem{Children: []cmdJsonItem{{Parallel: true, Children: []cmdJsonItem{{Value: check}}}}}},
+		{"并行中嵌套步骤", cmdJsonItem{Parallel: true, Children: []cmdJsonItem{{Children: []cmdJsonItem{{Value: check}}}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("HOME", t.TempDir())
+			if _, err := runCmdItem(tt.item); err != nil {
+				t.Errorf("runCmdItem() 错误为 %v", err)
+			}
+		})
+	}
+}
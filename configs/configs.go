@@ -0,0 +1,8 @@
+package configs
+
+import _ "embed"
+
+// 内置的默认配置，dora init 未指定模板时使用
+//
+//go:embed dora.config.json
+var DefaultConfig []byte
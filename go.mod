@@ -8,7 +8,14 @@ require (
 	github.com/spf13/cobra v1.8.1
 )
 
-require golang.org/x/term v0.24.0
+require (
+	github.com/BurntSushi/toml v1.3.2
+	github.com/bmatcuk/doublestar/v4 v4.6.1
+	github.com/creack/pty v1.1.24
+	github.com/muesli/cancelreader v0.2.2
+	golang.org/x/term v0.24.0
+	gopkg.in/yaml.v3 v3.0.1
+)
 
 require (
 	github.com/aymanbagabas/go-osc52/v2 v2.0.1 // indirect
@@ -25,7 +32,6 @@ require (
 	github.com/mattn/go-runewidth v0.0.16 // indirect
 	github.com/mattn/go-tty v0.0.3 // indirect
 	github.com/muesli/ansi v0.0.0-20230316100256-276c6243b2f6 // indirect
-	github.com/muesli/cancelreader v0.2.2 // indirect
 	github.com/muesli/termenv v0.15.2 // indirect
 	github.com/pkg/term v1.2.0-beta.2 // indirect
 	github.com/rivo/uniseg v0.4.7 // indirect
@@ -17,7 +17,6 @@ import (
 	"time"
 
 	"github.com/atotto/clipboard"
-	terminal "golang.org/x/term"
 )
 
 // 获取用户的路径
@@ -29,17 +28,41 @@ func GetUserHomePath() string {
 	return dirPath
 }
 
+// 将路径开头的~展开为用户目录，只处理单独的~和~/开头的路径，其他位置的~原样保留
+func ExpandHomeDir(path string) string {
+	if path == "~" {
+		return GetUserHomePath()
+	}
+	if strings.HasPrefix(path, "~/") || strings.HasPrefix(path, "~"+string(filepath.Separator)) {
+		return filepath.Join(GetUserHomePath(), path[2:])
+	}
+	return path
+}
+
 // 获取工作目录
 func GetWorkDir() string {
 	workingDir, _ := os.Getwd()
 	return workingDir
 }
 
-// 获取配置文件路径
-func GetDoraConfigPath() string {
+// 获取配置文件所在目录，用户目录/dora
+func GetDoraConfigDir() string {
 	userHomeDir, _ := os.UserHomeDir()
-	configPath := filepath.Join(userHomeDir, "dora/.config.json")
-	return configPath
+	return filepath.Join(userHomeDir, "dora")
+}
+
+// dora配置文件的候选文件名，按顺序使用第一个存在的
+var doraConfigNames = []string{".config.json", ".config.yaml", ".config.yml", ".config.toml"}
+
+// 获取配置文件路径，都不存在时返回.config.json
+func GetDoraConfigPath() string {
+	for _, name := range doraConfigNames {
+		configPath := filepath.Join(GetDoraConfigDir(), name)
+		if _, err := os.Stat(configPath); err == nil {
+			return configPath
+		}
+	}
+	return filepath.Join(GetDoraConfigDir(), doraConfigNames[0])
 }
 
 // 安全创建文件，避免文件夹不存在的情况
@@ -52,49 +75,9 @@ func SafeWriteFile(filePath string, content []byte) {
 	os.WriteFile(filePath, content, 0644)
 }
 
-// 是否是要调用终端的vim
-func isCallTerminalVim(command string) bool {
-	parts := strings.Fields(command)
-	isGitCommit := false
-	isVim := false
-	if len(parts) > 1 {
-		isGitCommit = parts[0] == "git" && parts[1] == "commit"
-	} else if len(parts) > 0 {
-		isVim = strings.HasPrefix(command, "vi")
-	}
-	return isGitCommit || isVim
-}
-
-// 调用系统的vim
-func callTerminalVim(command string) {
-	// 获取当前终端
-	fd := int(os.Stdin.Fd())
-
-	// 设置终端为原始模式
-	oldState, err := terminal.MakeRaw(fd)
-	if err != nil {
-		panic(err)
-	}
-	defer terminal.Restore(fd, oldState)
-
-	// 调用 Vim
-	parts := strings.Fields(command) // 使用 Fields 分割以处理空格
-	process := parts[0]
-	args := parts[1:]
-	cmd := exec.Command(process, args...)
-	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	err = cmd.Run()
-
-	if err != nil {
-		panic(err)
-	}
-}
-
 // 执行长命令
 func RunCommand(command string) (string, error) {
-	cmd := exec.Command("bash", "-c", command) // 使用 bash 运行命令
+	cmd := NewShellCommand(command) // 使用 bash 运行命令
 	var out bytes.Buffer
 	cmd.Stdout = &out
 	cmd.Stderr = &out
@@ -105,16 +88,20 @@ func RunCommand(command string) (string, error) {
 	return out.String(), nil
 }
 
+// 执行命令，命令中的 ${secret:NAME} 在执行时替换，日志只输出替换前的命令
 func RunCommandWithLog(command string) error {
 	log.Println("执行命令：", command)
-	// 如果是要调用vi的，则需要额外处理，git commit，vi
-	if isCallTerminalVim(command) {
-		callTerminalVim(command)
-		return nil
+	start := time.Now()
+	dir := GetWorkDir()
+	resolved, _, err := ResolveSecrets(command)
+	if err != nil {
+		fmt.Println(err)
+		return err
 	}
 	// 如果是调用cd命令，使用Chdir进入目录
-	if strings.HasPrefix(command, "cd") {
-		parts := strings.Fields(command)
+	if strings.HasPrefix(resolved, "cd") {
+		parts := strings.Fields(resolved)
+		exitCode := 0
 		if len(parts) > 1 {
 			targetDir := parts[1]
 			if strings.Contains(targetDir, "~") {
@@ -123,18 +110,21 @@ func RunCommandWithLog(command string) error {
 			}
 			if err := os.Chdir(targetDir); err != nil {
 				fmt.Printf("切换到目录 %s 失败: %v\n", targetDir, err)
+				exitCode = 1
 			}
 		}
+		RecordRun(command, dir, start, exitCode, "")
 		return nil
 	}
-	cmd := exec.Command("bash", "-c", command) // 使用 bash 运行命令
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	err := cmd.Run()
+	cmd := NewShellCommand(resolved) // 使用 bash 运行命令
+	wait, err := StartWithPty(cmd, os.Stdout)
 	if err != nil {
+		RecordRun(command, dir, start, -1, "")
 		return err
 	}
-	return nil
+	err = wait()
+	RecordRun(command, dir, start, ExitCodeOf(err), "")
+	return err
 }
 
 // 获取git根目录
@@ -390,6 +380,7 @@ func generateRegexFromDateFormat(dateFormat string) string {
 		"15":   `\d{2}`, // 时
 		"04":   `\d{2}`, // 分
 		"05":   `\d{2}`, // 秒
+		"000":  `\d{3}`, // 毫秒
 	}
 
 	// 用于构建正则表达式的字符串
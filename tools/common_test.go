@@ -0,0 +1,31 @@
+package tools
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestExpandHomeDir(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"~", home},
+		{"~/", home},
+		{"~/src/dora", filepath.Join(home, "src", "dora")},
+		{"~other/src", "~other/src"},
+		{"backup~/src", "backup~/src"},
+		{"/tmp/a~b", "/tmp/a~b"},
+		{"src/~", "src/~"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := ExpandHomeDir(tt.path); got != tt.want {
+			t.Errorf("ExpandHomeDir(%q) = %q，期望 %q", tt.path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package tools
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 动态提示的一个候选项
+type Completion struct {
+	Text string
+	Desc string
+}
+
+// 获取候选项的外部命令最长等待时间，避免卡住输入
+const completionCommandTimeout = 2 * time.Second
+
+// 执行命令，按行返回非空的输出，失败时返回nil
+func completionCommandLines(dir string, name string, args ...string) []string {
+	ctx, cancel := context.WithTimeout(context.Background(), completionCommandTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return nil
+	}
+	lines := []string{}
+	for _, line := range strings.Split(string(out), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// 路径的候选项，word为正在输入的路径，返回word所在目录下的文件，目录以/结尾
+// 以.开头时才显示隐藏文件，dirOnly只返回目录
+func PathCompletions(dir string, word string, dirOnly bool) []Completion {
+	prefix := ""
+	if i := strings.LastIndex(word, "/"); i != -1 {
+		prefix = word[:i+1]
+	}
+	listDir := prefix
+	if strings.HasPrefix(listDir, "~") {
+		listDir = GetUserHomePath() + listDir[1:]
+	}
+	if !filepath.IsAbs(listDir) {
+		listDir = filepath.Join(dir, listDir)
+	}
+	entries, err := os.ReadDir(listDir)
+	if err != nil {
+		return nil
+	}
+
+	showHidden := strings.HasPrefix(word[len(prefix):], ".")
+	completions := []Completion{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasPrefix(name, ".") && !showHidden {
+			continue
+		}
+		isDir := entry.IsDir()
+		// 指向目录的链接也按目录处理
+		if entry.Type()&os.ModeSymlink != 0 {
+			if fi, err := os.Stat(filepath.Join(listDir, name)); err == nil {
+				isDir = fi.IsDir()
+			}
+		}
+		if isDir {
+			completions = append(completions, Completion{Text: prefix + name + "/", Desc: "目录"})
+		} else if !dirOnly {
+			completions = append(completions, Completion{Text: prefix + name, Desc: "文件"})
+		}
+	}
+	return completions
+}
+
+// git本地分支和远程分支
+func GitBranchCompletions(dir string) []Completion {
+	completions := []Completion{}
+	for _, branch := range completionCommandLines(dir, "git", "branch", "--format=%(refname:short)") {
+		completions = append(completions, Completion{Text: branch, Desc: "分支"})
+	}
+	for _, branch := range completionCommandLines(dir, "git", "branch", "-r", "--format=%(refname:short)") {
+		if strings.HasSuffix(branch, "/HEAD") {
+			continue
+		}
+		completions = append(completions, Completion{Text: branch, Desc: "远程分支"})
+	}
+	return completions
+}
+
+// git的远程仓库
+func GitRemoteCompletions(dir string) []Completion {
+	completions := []Completion{}
+	for _, remote := range completionCommandLines(dir, "git", "remote") {
+		completions = append(completions, Completion{Text: remote, Desc: "远程仓库"})
+	}
+	return completions
+}
+
+// 从dir向上查找最近的文件
+func findUp(dir string, name string) string {
+	for {
+		filePath := filepath.Join(dir, name)
+		if _, err := os.Stat(filePath); err == nil {
+			return filePath
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// 最近的package.json中的scripts，说明为脚本内容
+func NpmScriptCompletions(dir string) []Completion {
+	packagePath := findUp(dir, "package.json")
+	if packagePath == "" {
+		return nil
+	}
+	content, err := os.ReadFile(packagePath)
+	if err != nil {
+		return nil
+	}
+	var pkg struct {
+		Scripts map[string]string `json:"scripts"`
+	}
+	if err := json.Unmarshal(content, &pkg); err != nil {
+		return nil
+	}
+	completions := []Completion{}
+	for name, script := range pkg.Scripts {
+		completions = append(completions, Completion{Text: name, Desc: script})
+	}
+	sort.Slice(completions, func(i, j int) bool {
+		return completions[i].Text < completions[j].Text
+	})
+	return completions
+}
+
+// Makefile中的目标，如 build: 或 build test:，跳过.PHONY等特殊目标、%模式规则和变量赋值
+var makeTargetPattern = regexp.MustCompile(`^([A-Za-z0-9_][A-Za-z0-9_./-]*(?:\s+[A-Za-z0-9_][A-Za-z0-9_./-]*)*)\s*:([^=]|$)`)
+
+// 当前目录Makefile中的目标
+func MakeTargetCompletions(dir string) []Completion {
+	makefilePath := ""
+	for _, name := range []string{"GNUmakefile", "makefile", "Makefile"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			makefilePath = filepath.Join(dir, name)
+			break
+		}
+	}
+	if makefilePath == "" {
+		return nil
+	}
+	file, err := os.Open(makefilePath)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	completions := []Completion{}
+	seen := map[string]bool{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		match := makeTargetPattern.FindStringSubmatch(scanner.Text())
+		if match == nil {
+			continue
+		}
+		for _, target := range strings.Fields(match[1]) {
+			if !seen[target] {
+				seen[target] = true
+				completions = append(completions, Completion{Text: target, Desc: "make"})
+			}
+		}
+	}
+	return completions
+}
+
+// docker容器名称，包含已停止的容器
+func DockerContainerCompletions() []Completion {
+	completions := []Completion{}
+	for _, line := range completionCommandLines("", "docker", "ps", "-a", "--format", "{{.Names}}\t{{.Status}}") {
+		name, status, _ := strings.Cut(line, "\t")
+		completions = append(completions, Completion{Text: name, Desc: status})
+	}
+	return completions
+}
@@ -0,0 +1,178 @@
+package tools
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// 按新的配置内容修改yaml，只替换有变化的节点，保留其余部分的注释和键的顺序
+func UpdateYAMLConfig(content []byte, config map[string]interface{}) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return nil, fmt.Errorf("解析yaml失败: %w", err)
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 {
+		return yaml.Marshal(config)
+	}
+	if err := updateYAMLNode(doc.Content[0], config); err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(&doc)
+}
+
+func updateYAMLNode(node *yaml.Node, value interface{}) error {
+	var current interface{}
+	if err := node.Decode(&current); err == nil && CanonicalJSON(current) == CanonicalJSON(value) {
+		return nil
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if node.Kind == yaml.MappingNode {
+			return updateYAMLMapping(node, v)
+		}
+	case []interface{}:
+		if node.Kind == yaml.SequenceNode {
+			return updateYAMLSequence(node, v)
+		}
+	}
+
+	replacement, err := newYAMLNode(value)
+	if err != nil {
+		return err
+	}
+	replacement.HeadComment = node.HeadComment
+	replacement.LineComment = node.LineComment
+	replacement.FootComment = node.FootComment
+	*node = *replacement
+	return nil
+}
+
+// 已有的键按原顺序更新或删除，新增的键按字母顺序追加到最后
+func updateYAMLMapping(node *yaml.Node, value map[string]interface{}) error {
+	content := []*yaml.Node{}
+	seen := map[string]bool{}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key := node.Content[i].Value
+		item, ok := value[key]
+		if !ok {
+			continue
+		}
+		seen[key] = true
+		if err := updateYAMLNode(node.Content[i+1], item); err != nil {
+			return err
+		}
+		content = append(content, node.Content[i], node.Content[i+1])
+	}
+
+	keys := []string{}
+	for key := range value {
+		if !seen[key] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		valueNode, err := newYAMLNode(value[key])
+		if err != nil {
+			return err
+		}
+		keyNode := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}
+		content = append(content, keyNode, valueNode)
+	}
+	node.Content = content
+	return nil
+}
+
+// 内容相同的项沿用原来的节点，其余按位置更新，多出的追加
+func updateYAMLSequence(node *yaml.Node, value []interface{}) error {
+	old := node.Content
+	oldJson := make([]string, len(old))
+	for i, item := range old {
+		var current interface{}
+		if err := item.Decode(&current); err == nil {
+			oldJson[i] = CanonicalJSON(current)
+		}
+	}
+	used := make([]bool, len(old))
+
+	content := []*yaml.Node{}
+	for i, item := range value {
+		itemJson := CanonicalJSON(item)
+		reused := false
+		for j := range old {
+			if !used[j] && oldJson[j] == itemJson {
+				used[j] = true
+				content = append(content, old[j])
+				reused = true
+				break
+			}
+		}
+		if reused {
+			continue
+		}
+		if i < len(old) && !used[i] {
+			used[i] = true
+			if err := updateYAMLNode(old[i], item); err != nil {
+				return err
+			}
+			content = append(content, old[i])
+			continue
+		}
+		itemNode, err := newYAMLNode(item)
+		if err != nil {
+			return err
+		}
+		content = append(content, itemNode)
+	}
+	node.Content = content
+	return nil
+}
+
+func newYAMLNode(value interface{}) (*yaml.Node, error) {
+	node := &yaml.Node{}
+	if err := node.Encode(value); err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+// 在toml配置的末尾追加 [[section]] 表，不重写已有内容
+// section已以 section = [...] 等其他形式定义时无法追加，返回错误
+func AppendTOMLTables(content []byte, section string, items []map[string]interface{}) ([]byte, error) {
+	var existing map[string]interface{}
+	if err := toml.Unmarshal(content, &existing); err != nil {
+		return nil, fmt.Errorf("解析toml失败: %w", err)
+	}
+	count := 0
+	if list, ok := existing[section].([]map[string]interface{}); ok {
+		count = len(list)
+	} else if _, ok := existing[section]; ok {
+		return nil, fmt.Errorf("toml配置中的 %s 不是 [[%s]] 表数组，无法追加", section, section)
+	}
+
+	var out bytes.Buffer
+	out.Write(content)
+	if len(content) > 0 && !bytes.HasSuffix(content, []byte("\n")) {
+		out.WriteString("\n")
+	}
+	out.WriteString("\n")
+	enc := toml.NewEncoder(&out)
+	enc.Indent = ""
+	if err := enc.Encode(map[string]interface{}{section: items}); err != nil {
+		return nil, err
+	}
+
+	var updated map[string]interface{}
+	if err := toml.Unmarshal(out.Bytes(), &updated); err != nil {
+		return nil, fmt.Errorf("toml配置中的 %s 无法追加: %w", section, err)
+	}
+	if list, ok := updated[section].([]map[string]interface{}); !ok || len(list) != count+len(items) {
+		return nil, fmt.Errorf("toml配置中的 %s 不是 [[%s]] 表数组，无法追加", section, section)
+	}
+	return out.Bytes(), nil
+}
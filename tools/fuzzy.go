@@ -0,0 +1,163 @@
+package tools
+
+import (
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// 模糊匹配的得分
+const (
+	fuzzyMatchScore       = 16
+	fuzzyConsecutiveBonus = 12
+	fuzzyBoundaryBonus    = 10
+	fuzzyFirstCharBonus   = 8
+	fuzzyCaseBonus        = 1
+	fuzzyGapPenalty       = 1
+	fuzzyMaxGapPenalty    = 12
+)
+
+// 是否为单词的开头：字符串开头、分隔符之后、驼峰的大写字母
+func isWordBoundary(runes []rune, i int) bool {
+	if i == 0 {
+		return true
+	}
+	prev, cur := runes[i-1], runes[i]
+	if strings.ContainsRune(" -_./:=@", prev) {
+		return true
+	}
+	return unicode.IsLower(prev) && unicode.IsUpper(cur)
+}
+
+// 计算pattern在text中的模糊匹配得分，不匹配时返回false
+// 连续匹配、单词开头、大小写一致的得分更高，跳过的字符越多得分越低
+// pattern中有大写字母时区分大小写
+func FuzzyScore(pattern string, text string) (int, bool) {
+	if pattern == "" {
+		return 0, true
+	}
+	caseSensitive := strings.ToLower(pattern) != pattern
+	patternRunes := []rune(pattern)
+	textRunes := []rune(text)
+	equal := func(a rune, b rune) bool {
+		if caseSensitive {
+			return a == b
+		}
+		return unicode.ToLower(a) == unicode.ToLower(b)
+	}
+
+	// 从每个可能的起点贪心匹配，取得分最高的
+	best, matched := 0, false
+	for start := range textRunes {
+		if !equal(patternRunes[0], textRunes[start]) {
+			continue
+		}
+		score, ok := fuzzyScoreFrom(patternRunes, textRunes, start, equal)
+		if ok && (!matched || score > best) {
+			best, matched = score, true
+		}
+	}
+	return best, matched
+}
+
+func fuzzyScoreFrom(pattern []rune, text []rune, start int, equal func(rune, rune) bool) (int, bool) {
+	score := 0
+	p, last := 0, -1
+	for i := start; i < len(text) && p < len(pattern); i++ {
+		if !equal(pattern[p], text[i]) {
+			continue
+		}
+		score += fuzzyMatchScore
+		if pattern[p] == text[i] {
+			score += fuzzyCaseBonus
+		}
+		if isWordBoundary(text, i) {
+			score += fuzzyBoundaryBonus
+		}
+		if last != -1 {
+			if i == last+1 {
+				score += fuzzyConsecutiveBonus
+			} else {
+				score -= int(math.Min(float64((i-last-1)*fuzzyGapPenalty), fuzzyMaxGapPenalty))
+			}
+		}
+		last = i
+		p++
+	}
+	if p < len(pattern) {
+		return 0, false
+	}
+	if start == 0 {
+		score += fuzzyFirstCharBonus
+	}
+	// 匹配的范围越接近整个字符串得分越高
+	score -= (len(text) - len(pattern)) / 8
+	return score, true
+}
+
+// 按模糊匹配得分排序，bonus为额外的得分，如执行频率
+func RankMatches[T any](arr []T, fieldKey string, searchKey string, bonus func(T) int) []T {
+	type scored struct {
+		item  T
+		score int
+	}
+	matches := []scored{}
+	for _, item := range arr {
+		val := reflect.ValueOf(item).FieldByName(fieldKey)
+		if !val.IsValid() || val.Kind() != reflect.String {
+			continue
+		}
+		score, ok := FuzzyScore(searchKey, val.String())
+		if !ok {
+			continue
+		}
+		if bonus != nil {
+			score += bonus(item)
+		}
+		matches = append(matches, scored{item: item, score: score})
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].score > matches[j].score
+	})
+	result := make([]T, 0, len(matches))
+	for _, match := range matches {
+		result = append(result, match.item)
+	}
+	return result
+}
+
+// 根据执行记录计算每条命令的frecency，越近、越频繁的命令分数越高
+func Frecency(records []RunRecord, now time.Time) map[string]float64 {
+	scores := map[string]float64{}
+	for _, record := range records {
+		scores[record.Command] += FrecencyWeight(now.Sub(record.Start))
+	}
+	return scores
+}
+
+// 一次执行按距今的时间计算的权重
+func FrecencyWeight(age time.Duration) float64 {
+	switch {
+	case age < 4*time.Hour:
+		return 100
+	case age < 24*time.Hour:
+		return 80
+	case age < 7*24*time.Hour:
+		return 60
+	case age < 30*24*time.Hour:
+		return 40
+	default:
+		return 20
+	}
+}
+
+// frecency转换为匹配的额外得分，取对数避免盖过匹配程度
+func FrecencyBonus(frecency float64) int {
+	if frecency <= 0 {
+		return 0
+	}
+	return int(12 * math.Log2(1+frecency/20))
+}
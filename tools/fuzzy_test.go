@@ -0,0 +1,103 @@
+package tools
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFuzzyScoreMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		text    string
+		want    bool
+	}{
+		{"", "anything", true},
+		{"gst", "git status", true},
+		{"dps", "docker ps", true},
+		{"GS", "git status", false},
+		{"GS", "GitStatus", true},
+		{"gs", "GitStatus", true},
+		{"sg", "git status", false},
+		{"状态", "查看git状态", true},
+		{"abc", "ab", false},
+	}
+	for _, tt := range tests {
+		if _, ok := FuzzyScore(tt.pattern, tt.text); ok != tt.want {
+			t.Errorf("FuzzyScore(%q, %q) 匹配为 %v，期望 %v", tt.pattern, tt.text, ok, tt.want)
+		}
+	}
+}
+
+func TestFuzzyScoreOrder(t *testing.T) {
+	// better的得分应高于worse
+	tests := []struct {
+		name    string
+		pattern string
+		better  string
+		worse   string
+	}{
+		{"连续匹配优先", "stat", "git status", "s-t-a-t"},
+		{"单词开头优先", "gs", "git status", "bugs"},
+		{"开头匹配优先", "git", "git log", "legit"},
+		{"大小写一致优先", "git", "git log", "GIT log"},
+		{"更短的文本优先", "ls", "ls", "ls -la --color=auto /tmp"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			better, ok := FuzzyScore(tt.pattern, tt.better)
+			if !ok {
+				t.Fatalf("%q 未匹配 %q", tt.pattern, tt.better)
+			}
+			worse, ok := FuzzyScore(tt.pattern, tt.worse)
+			if !ok {
+				t.Fatalf("%q 未匹配 %q", tt.pattern, tt.worse)
+			}
+			if better <= worse {
+				t.Errorf("%q 的得分 %d 不高于 %q 的得分 %d", tt.better, better, tt.worse, worse)
+			}
+		})
+	}
+}
+
+func TestRankMatches(t *testing.T) {
+	type item struct {
+		Label string
+		Count int
+	}
+	items := []item{
+		{Label: "legit"},
+		{Label: "git log", Count: 0},
+		{Label: "docker ps"},
+		{Label: "git status", Count: 10},
+	}
+	labels := func(items []item) []string {
+		result := []string{}
+		for _, item := range items {
+			result = append(result, item.Label)
+		}
+		return result
+	}
+
+	tests := []struct {
+		name   string
+		search string
+		bonus  func(item) int
+		want   []string
+	}{
+		{"过滤不匹配的项", "dps", nil, []string{"docker ps"}},
+		{"按得分排序", "git", nil, []string{"git log", "git status", "legit"}},
+		{"额外得分参与排序", "git", func(i item) int { return i.Count * 10 }, []string{"git status", "git log", "legit"}},
+		{"没有匹配", "xyz", nil, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := labels(RankMatches(items, "Label", tt.search, tt.bonus)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("排序结果为 %v，期望 %v", got, tt.want)
+			}
+		})
+	}
+
+	if got := RankMatches(items, "Missing", "git", nil); len(got) != 0 {
+		t.Errorf("字段不存在时应没有结果，实际为 %v", got)
+	}
+}
@@ -0,0 +1,138 @@
+package tools
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// 配置快照文件名中的时间格式
+const ConfigHistoryDateFormat = "2006_01_02_150405.000"
+
+// 配置快照目录，位于用户目录/dora/history
+func GetConfigHistoryDir() string {
+	return filepath.Join(GetDoraConfigDir(), "history")
+}
+
+// 按时间倒序列出所有配置快照的文件名
+func ListConfigSnapshots() ([]string, error) {
+	entries, err := os.ReadDir(GetConfigHistoryDir())
+	if os.IsNotExist(err) {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	names := []string{}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+	return SortSliceByInlineDate(names, ConfigHistoryDateFormat, false), nil
+}
+
+// 保存配置文件的快照，reason为触发写入的操作，如 pull、init
+// 内容和最新的快照相同时不重复保存，配置文件不存在时不保存
+func SaveConfigSnapshot(configPath string, reason string) (string, error) {
+	content, err := os.ReadFile(configPath)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	snapshots, err := ListConfigSnapshots()
+	if err != nil {
+		return "", err
+	}
+	// 快照按文件名中的时间排序，保证新快照的时间晚于最新的快照
+	now := time.Now().Truncate(time.Millisecond)
+	if len(snapshots) > 0 {
+		latest, err := os.ReadFile(filepath.Join(GetConfigHistoryDir(), snapshots[0]))
+		if err == nil && bytes.Equal(latest, content) {
+			return "", nil
+		}
+		format := ConfigHistoryDateFormat
+		if len(snapshots[0]) >= len(format) {
+			latestTime, err := time.ParseInLocation(format, snapshots[0][:len(format)], time.Local)
+			if err == nil && !now.After(latestTime) {
+				now = latestTime.Add(time.Millisecond)
+			}
+		}
+	}
+
+	historyDir := GetConfigHistoryDir()
+	if err := os.MkdirAll(historyDir, 0755); err != nil {
+		return "", err
+	}
+	name := fmt.Sprintf("%s_%s%s", now.Format(ConfigHistoryDateFormat), reason, filepath.Ext(configPath))
+	return name, os.WriteFile(filepath.Join(historyDir, name), content, 0644)
+}
+
+// 写入配置文件，写入前后各保存一次快照，保证可以回滚到写入前的内容
+func WriteConfigWithHistory(configPath string, content []byte, reason string) error {
+	if _, err := SaveConfigSnapshot(configPath, "backup"); err != nil {
+		fmt.Println("保存配置快照失败:", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(configPath, content, 0644); err != nil {
+		return err
+	}
+	if _, err := SaveConfigSnapshot(configPath, reason); err != nil {
+		fmt.Println("保存配置快照失败:", err)
+	}
+	return nil
+}
+
+// 按行比较两份内容，返回带有 -/+ 前缀的差异行
+func DiffLines(oldContent string, newContent string) []string {
+	oldLines := strings.Split(strings.TrimRight(oldContent, "\n"), "\n")
+	newLines := strings.Split(strings.TrimRight(newContent, "\n"), "\n")
+	if oldContent == "" {
+		oldLines = []string{}
+	}
+	if newContent == "" {
+		newLines = []string{}
+	}
+
+	// 最长公共子序列
+	lcs := make([][]int, len(oldLines)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(newLines)+1)
+	}
+	for i := len(oldLines) - 1; i >= 0; i-- {
+		for j := len(newLines) - 1; j >= 0; j-- {
+			if oldLines[i] == newLines[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	diff := []string{}
+	i, j := 0, 0
+	for i < len(oldLines) || j < len(newLines) {
+		switch {
+		case i < len(oldLines) && j < len(newLines) && oldLines[i] == newLines[j]:
+			i++
+			j++
+		case i < len(oldLines) && (j == len(newLines) || lcs[i+1][j] >= lcs[i][j+1]):
+			diff = append(diff, "- "+oldLines[i])
+			i++
+		default:
+			diff = append(diff, "+ "+newLines[j])
+			j++
+		}
+	}
+	return diff
+}
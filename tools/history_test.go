@@ -0,0 +1,116 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// 快照名中的操作，如 2024_01_02_150405.000_pull.json 中的 pull
+func snapshotReasons(names []string) []string {
+	reasons := []string{}
+	for _, name := range names {
+		rest := strings.TrimSuffix(name[len(ConfigHistoryDateFormat)+1:], filepath.Ext(name))
+		reasons = append(reasons, rest)
+	}
+	return reasons
+}
+
+func TestConfigSnapshots(t *testing.T) {
+	tests := []struct {
+		name string
+		// 依次写入的配置内容和操作
+		writes []string
+		// 快照按时间倒序的操作列表
+		want []string
+	}{
+		{"首次写入只保存写入后的快照", []string{"a:init"}, []string{"init"}},
+		{"写入前保存备份快照", []string{"a:init", "b:pull"}, []string{"pull", "init"}},
+		{"内容未变时不重复保存", []string{"a:init", "a:pull", "a:edit"}, []string{"init"}},
+		{"手动修改后写入先备份再保存", []string{"a:init", "!b", "c:pull"}, []string{"pull", "backup", "init"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("HOME", t.TempDir())
+			configPath := filepath.Join(GetDoraConfigDir(), ".config.json")
+			for _, write := range tt.writes {
+				// !开头表示不经过快照直接修改配置文件
+				if strings.HasPrefix(write, "!") {
+					writeTestFile(t, configPath, write[1:])
+					continue
+				}
+				content, reason, _ := strings.Cut(write, ":")
+				if err := WriteConfigWithHistory(configPath, []byte(content), reason); err != nil {
+					t.Fatalf("写入配置失败: %v", err)
+				}
+			}
+
+			snapshots, err := ListConfigSnapshots()
+			if err != nil {
+				t.Fatalf("读取快照失败: %v", err)
+			}
+			if got := snapshotReasons(snapshots); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("快照为 %v，期望 %v", got, tt.want)
+			}
+			// 最新的快照和当前配置相同，可以回滚到任意一次写入前
+			if len(snapshots) > 0 {
+				latest, _ := os.ReadFile(filepath.Join(GetConfigHistoryDir(), snapshots[0]))
+				current, _ := os.ReadFile(configPath)
+				if string(latest) != string(current) {
+					t.Errorf("最新快照 %q 和当前配置 %q 不同", latest, current)
+				}
+			}
+		})
+	}
+}
+
+func TestSaveConfigSnapshotOrder(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	configPath := filepath.Join(GetDoraConfigDir(), ".config.json")
+
+	// 同一毫秒内的多次保存，文件名的时间依然递增
+	names := []string{}
+	for _, content := range []string{"1", "2", "3", "4", "5"} {
+		writeTestFile(t, configPath, content)
+		name, err := SaveConfigSnapshot(configPath, "edit")
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, name)
+	}
+	for i := 1; i < len(names); i++ {
+		if names[i] <= names[i-1] {
+			t.Errorf("快照 %s 不晚于 %s", names[i], names[i-1])
+		}
+	}
+
+	snapshots, _ := ListConfigSnapshots()
+	if len(snapshots) != len(names) || snapshots[0] != names[len(names)-1] {
+		t.Errorf("快照列表为 %v，期望最新的 %s 在最前", snapshots, names[len(names)-1])
+	}
+}
+
+func TestDiffLines(t *testing.T) {
+	tests := []struct {
+		name       string
+		oldContent string
+		newContent string
+		want       []string
+	}{
+		{"相同内容", "a\nb\n", "a\nb", []string{}},
+		{"新增行", "a\nc", "a\nb\nc", []string{"+ b"}},
+		{"删除行", "a\nb\nc", "a\nc", []string{"- b"}},
+		{"修改行", "a\nb\nc", "a\nx\nc", []string{"- b", "+ x"}},
+		{"从空内容", "", "a\nb", []string{"+ a", "+ b"}},
+		{"到空内容", "a", "", []string{"- a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DiffLines(tt.oldContent, tt.newContent); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DiffLines(%q, %q) = %v，期望 %v", tt.oldContent, tt.newContent, got, tt.want)
+			}
+		})
+	}
+}
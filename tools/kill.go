@@ -177,11 +177,11 @@ func KillProcess(args *[]string, silence bool) {
 			seen := make(map[string]bool)
 			var uniqueProcesses []ProcessItem
 			for _, process := range auxPidList {
-				if strings.Contains(process.Command, currentRunCommand) || seen[string(process.Pid)] {
+				if strings.Contains(process.Command, currentRunCommand) || seen[strconv.Itoa(process.Pid)] {
 					continue
 				}
 				uniqueProcesses = append(uniqueProcesses, process)
-				seen[string(process.Pid)] = true
+				seen[strconv.Itoa(process.Pid)] = true
 			}
 			pidInfoList = append(pidInfoList, uniqueProcesses...)
 		} else {
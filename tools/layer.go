@@ -0,0 +1,207 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// 环境变量覆盖的前缀，如 DORA_API_KEY 覆盖 api_key
+const ConfigEnvPrefix = "DORA_"
+
+// 项目配置所在目录，如 项目/.dora/config.json
+const projectConfigDir = ".dora"
+
+// 合并时需要拼接的列表，以及列表项的唯一标识字段
+var mergeListKeys = map[string]string{
+	"commands": "value",
+	"prompts":  "cmd",
+	"notes":    "value",
+}
+
+// 一层配置
+type ConfigLayer struct {
+	Name string
+	Path string
+	Data map[string]interface{}
+}
+
+// 合并后的配置，记录每个字段和列表项的来源
+type ResolvedConfig struct {
+	Layers      []ConfigLayer
+	Data        map[string]interface{}
+	Sources     map[string]string
+	ItemSources map[string][]string
+}
+
+// 查找项目配置：从当前目录向上查找到git根目录的.dora/config.*，越靠近当前目录优先级越高
+// 返回的列表按优先级从低到高排列
+func findProjectConfigPaths() []string {
+	workDir := GetWorkDir()
+	gitRootDir, err := GetGitRootDir()
+	if err != nil || !isSubPath(workDir, gitRootDir) {
+		gitRootDir = workDir
+	}
+
+	paths := []string{}
+	for dir := workDir; ; dir = filepath.Dir(dir) {
+		for _, name := range doraConfigNames {
+			configPath := filepath.Join(dir, projectConfigDir, strings.TrimPrefix(name, "."))
+			if _, err := os.Stat(configPath); err == nil {
+				paths = append([]string{configPath}, paths...)
+				break
+			}
+		}
+		if dir == gitRootDir || filepath.Dir(dir) == dir {
+			break
+		}
+	}
+	return paths
+}
+
+// path是否在dir目录下
+func isSubPath(path string, dir string) bool {
+	relPath, err := filepath.Rel(dir, path)
+	return err == nil && !strings.HasPrefix(relPath, "..")
+}
+
+// 读取一个配置文件为通用的map
+func readConfigLayer(name string, configPath string) (ConfigLayer, error) {
+	layer := ConfigLayer{Name: name, Path: configPath, Data: map[string]interface{}{}}
+	if err := ReadJsonFile(configPath, &layer.Data); err != nil {
+		return layer, fmt.Errorf("读取配置 %s 失败: %w", configPath, err)
+	}
+	return layer, nil
+}
+
+// 读取DORA_*环境变量，值为json时按json解析，否则作为字符串
+// DORA_CHANGED_* 为dora watch注入给命令的变量，不作为配置
+func readEnvConfigLayer() ConfigLayer {
+	layer := ConfigLayer{Name: "env", Path: ConfigEnvPrefix + "*", Data: map[string]interface{}{}}
+	for _, env := range os.Environ() {
+		key, value, ok := strings.Cut(env, "=")
+		if !ok || !strings.HasPrefix(key, ConfigEnvPrefix) || strings.HasPrefix(key, ConfigEnvPrefix+"CHANGED_") {
+			continue
+		}
+		field := strings.ToLower(strings.TrimPrefix(key, ConfigEnvPrefix))
+		var parsed interface{}
+		trimmed := strings.TrimSpace(value)
+		if (strings.HasPrefix(trimmed, "[") || strings.HasPrefix(trimmed, "{")) && json.Unmarshal([]byte(trimmed), &parsed) == nil {
+			layer.Data[field] = parsed
+		} else {
+			layer.Data[field] = value
+		}
+	}
+	return layer
+}
+
+// 按优先级从低到高读取所有配置层：全局配置、项目配置、环境变量
+func LoadConfigLayers() ([]ConfigLayer, error) {
+	layers := []ConfigLayer{}
+
+	globalPath := GetDoraConfigPath()
+	if _, err := os.Stat(globalPath); err == nil {
+		layer, err := readConfigLayer("global", globalPath)
+		if err != nil {
+			return nil, err
+		}
+		layers = append(layers, layer)
+	}
+
+	for _, configPath := range findProjectConfigPaths() {
+		layer, err := readConfigLayer("project", configPath)
+		if err != nil {
+			return nil, err
+		}
+		layers = append(layers, layer)
+	}
+
+	if envLayer := readEnvConfigLayer(); len(envLayer.Data) > 0 {
+		layers = append(layers, envLayer)
+	}
+
+	if len(layers) == 0 {
+		return nil, fmt.Errorf("未找到配置文件: %s，可执行 dora init 生成", globalPath)
+	}
+	return layers, nil
+}
+
+// 合并所有配置层
+// commands、prompts、notes按标识字段合并，高优先级的同名项替换低优先级的，其他字段直接覆盖
+func ResolveConfig() (*ResolvedConfig, error) {
+	layers, err := LoadConfigLayers()
+	if err != nil {
+		return nil, err
+	}
+
+	resolved := &ResolvedConfig{
+		Layers:      layers,
+		Data:        map[string]interface{}{},
+		Sources:     map[string]string{},
+		ItemSources: map[string][]string{},
+	}
+	for _, layer := range layers {
+		for key, value := range layer.Data {
+			source := fmt.Sprintf("%s %s", layer.Name, layer.Path)
+			if layer.Name == "env" {
+				source = fmt.Sprintf("%s %s%s", layer.Name, ConfigEnvPrefix, strings.ToUpper(key))
+			}
+			idKey, isList := mergeListKeys[key]
+			items, isArray := value.([]interface{})
+			if !isList || !isArray {
+				// 高优先级的非列表值替换了之前合并的列表，列表项的来源不再有效
+				resolved.Data[key] = value
+				resolved.Sources[key] = source
+				delete(resolved.ItemSources, key)
+				continue
+			}
+			resolved.mergeList(key, idKey, items, source)
+		}
+	}
+	return resolved, nil
+}
+
+func (r *ResolvedConfig) mergeList(key string, idKey string, items []interface{}, source string) {
+	merged, _ := r.Data[key].([]interface{})
+	sources := r.ItemSources[key]
+	for _, item := range items {
+		replaced := false
+		if id := listItemId(item, idKey); id != "" {
+			for i, existing := range merged {
+				if listItemId(existing, idKey) == id {
+					merged[i] = item
+					sources[i] = source
+					replaced = true
+					break
+				}
+			}
+		}
+		if !replaced {
+			merged = append(merged, item)
+			sources = append(sources, source)
+		}
+	}
+	r.Data[key] = merged
+	r.ItemSources[key] = sources
+	r.Sources[key] = "merged"
+}
+
+func listItemId(item interface{}, idKey string) string {
+	fields, ok := item.(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	id, _ := fields[idKey].(string)
+	return id
+}
+
+// 将合并后的配置解码为指定的类型
+func DecodeResolvedConfig[T any](resolved *ResolvedConfig, out *T) error {
+	data, err := json.Marshal(resolved.Data)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
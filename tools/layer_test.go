@@ -0,0 +1,177 @@
+package tools
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// 在临时目录中准备全局配置和项目配置，并切换到项目目录
+func setupConfigLayers(t *testing.T, global string, project string, env map[string]string) {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	if global != "" {
+		writeTestFile(t, filepath.Join(home, "dora", ".config.json"), global)
+	}
+	workDir := t.TempDir()
+	if project != "" {
+		writeTestFile(t, filepath.Join(workDir, ".dora", "config.json"), project)
+	}
+	for key, value := range env {
+		t.Setenv(key, value)
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(workDir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(cwd) })
+}
+
+func writeTestFile(t *testing.T, path string, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// 来源只比较层的名称，如 global、project、env
+func sourceNames(sources []string) []string {
+	names := []string{}
+	for _, source := range sources {
+		name, _, _ := strings.Cut(source, " ")
+		names = append(names, name)
+	}
+	return names
+}
+
+func TestResolveConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		global  string
+		project string
+		env     map[string]string
+		key     string
+		want    string
+		// 列表项的来源，不是列表时为空
+		wantItemSources []string
+		// 字段的来源
+		wantSource string
+	}{
+		{
+			name:            "同标识的列表项由高优先级替换",
+			global:          `{"commands":[{"label":"列表","value":"ls"},{"label":"目录","value":"pwd"}]}`,
+			project:         `{"commands":[{"label":"项目列表","value":"ls"},{"label":"日期","value":"date"}]}`,
+			key:             "commands",
+			want:            `[{"label":"项目列表","value":"ls"},{"label":"目录","value":"pwd"},{"label":"日期","value":"date"}]`,
+			wantItemSources: []string{"project", "global", "project"},
+			wantSource:      "merged",
+		},
+		{
+			name:            "prompts按cmd合并",
+			global:          `{"prompts":[{"cmd":"git","options":["status"]}]}`,
+			project:         `{"prompts":[{"cmd":"git","options":["log"]}]}`,
+			key:             "prompts",
+			want:            `[{"cmd":"git","options":["log"]}]`,
+			wantItemSources: []string{"project"},
+			wantSource:      "merged",
+		},
+		{
+			name:            "没有标识字段的项追加",
+			global:          `{"notes":[{"label":"a"}]}`,
+			project:         `{"notes":[{"label":"a"}]}`,
+			key:             "notes",
+			want:            `[{"label":"a"},{"label":"a"}]`,
+			wantItemSources: []string{"global", "project"},
+			wantSource:      "merged",
+		},
+		{
+			name:       "普通字段由高优先级覆盖",
+			global:     `{"theme":"global"}`,
+			project:    `{"theme":"project"}`,
+			env:        map[string]string{"DORA_THEME": "env"},
+			key:        "theme",
+			want:       `"env"`,
+			wantSource: "env",
+		},
+		{
+			name:            "环境变量中的json列表参与合并",
+			global:          `{"notes":[{"label":"a","value":"1"}]}`,
+			env:             map[string]string{"DORA_NOTES": `[{"label":"b","value":"2"}]`},
+			key:             "notes",
+			want:            `[{"label":"a","value":"1"},{"label":"b","value":"2"}]`,
+			wantItemSources: []string{"global", "env"},
+			wantSource:      "merged",
+		},
+		{
+			name:       "高优先级的非列表值替换合并的列表",
+			global:     `{"commands":[{"label":"列表","value":"ls"}]}`,
+			project:    `{"commands":[{"label":"目录","value":"pwd"}]}`,
+			env:        map[string]string{"DORA_COMMANDS": "foo"},
+			key:        "commands",
+			want:       `"foo"`,
+			wantSource: "env",
+		},
+		{
+			name:            "列表替换低优先级的非列表值",
+			global:          `{"notes":"x"}`,
+			project:         `{"notes":[{"label":"a","value":"1"}]}`,
+			key:             "notes",
+			want:            `[{"label":"a","value":"1"}]`,
+			wantItemSources: []string{"project"},
+			wantSource:      "merged",
+		},
+		{
+			name:       "watch注入的DORA_CHANGED_*不作为配置",
+			global:     `{}`,
+			env:        map[string]string{"DORA_CHANGED_FILE": "a.go"},
+			key:        "changed_file",
+			want:       `null`,
+			wantSource: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupConfigLayers(t, tt.global, tt.project, tt.env)
+			resolved, err := ResolveConfig()
+			if err != nil {
+				t.Fatalf("合并配置失败: %v", err)
+			}
+			if got := toJSON(resolved.Data[tt.key]); got != toJSON(mustParseJSON(t, tt.want)) {
+				t.Errorf("%s 为 %s，期望 %s", tt.key, got, tt.want)
+			}
+			if got := sourceNames(resolved.ItemSources[tt.key]); len(got) > 0 || len(tt.wantItemSources) > 0 {
+				if !reflect.DeepEqual(got, tt.wantItemSources) {
+					t.Errorf("列表项来源为 %v，期望 %v", got, tt.wantItemSources)
+				}
+			}
+			if got := sourceNames([]string{resolved.Sources[tt.key]})[0]; got != tt.wantSource {
+				t.Errorf("来源为 %q，期望 %q", got, tt.wantSource)
+			}
+		})
+	}
+}
+
+func mustParseJSON(t *testing.T, content string) interface{} {
+	t.Helper()
+	var value interface{}
+	if err := json.Unmarshal([]byte(content), &value); err != nil {
+		t.Fatalf("解析 %s 失败: %v", content, err)
+	}
+	return value
+}
+
+func toJSON(value interface{}) string {
+	data, _ := json.Marshal(value)
+	return string(data)
+}
@@ -0,0 +1,139 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// 三方合并的冲突，Local或Remote为nil表示该侧已删除
+type MergeConflict struct {
+	Section string
+	Id      string
+	Local   interface{}
+	Remote  interface{}
+}
+
+// 冲突的处理函数，返回最终使用的值，返回nil表示删除
+type MergeResolver func(conflict MergeConflict) (interface{}, error)
+
+// 转换为稳定的json字符串，用于比较是否相同
+func CanonicalJSON(value interface{}) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
+
+// 两个值是否相同，nil表示不存在
+func sameValue(a interface{}, b interface{}) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return CanonicalJSON(a) == CanonicalJSON(b)
+}
+
+// 以base为共同祖先合并local和remote
+// 只有一侧修改时使用修改的一侧，两侧修改不同时交给resolve处理
+func mergeValue(base, local, remote interface{}, conflict MergeConflict, resolve MergeResolver) (interface{}, error) {
+	switch {
+	case sameValue(local, remote):
+		return local, nil
+	case sameValue(local, base):
+		return remote, nil
+	case sameValue(remote, base):
+		return local, nil
+	}
+	conflict.Local = local
+	conflict.Remote = remote
+	return resolve(conflict)
+}
+
+// 列表项的标识，没有标识字段时使用整项内容
+func mergeItemId(item interface{}, idKey string) string {
+	if id := listItemId(item, idKey); id != "" {
+		return id
+	}
+	return CanonicalJSON(item)
+}
+
+func indexMergeItems(items []interface{}, idKey string) map[string]interface{} {
+	index := map[string]interface{}{}
+	for _, item := range items {
+		index[mergeItemId(item, idKey)] = item
+	}
+	return index
+}
+
+// 按标识合并列表，保持本地的顺序，远程新增的追加在后面
+func mergeList(section string, idKey string, base, local, remote []interface{}, resolve MergeResolver) ([]interface{}, error) {
+	baseIndex := indexMergeItems(base, idKey)
+	localIndex := indexMergeItems(local, idKey)
+	remoteIndex := indexMergeItems(remote, idKey)
+
+	ids := []string{}
+	seen := map[string]bool{}
+	for _, items := range [][]interface{}{local, remote} {
+		for _, item := range items {
+			id := mergeItemId(item, idKey)
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+
+	merged := []interface{}{}
+	for _, id := range ids {
+		conflict := MergeConflict{Section: section, Id: id}
+		value, err := mergeValue(baseIndex[id], localIndex[id], remoteIndex[id], conflict, resolve)
+		if err != nil {
+			return nil, err
+		}
+		if value != nil {
+			merged = append(merged, value)
+		}
+	}
+	return merged, nil
+}
+
+// 三方合并配置，commands、prompts、notes按列表项合并，其他字段整体合并
+func ThreeWayMerge(base, local, remote map[string]interface{}, resolve MergeResolver) (map[string]interface{}, error) {
+	keys := []string{}
+	seen := map[string]bool{}
+	for _, config := range []map[string]interface{}{local, remote} {
+		for key := range config {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+
+	merged := map[string]interface{}{}
+	for _, key := range keys {
+		idKey, isList := mergeListKeys[key]
+		localItems, localOk := local[key].([]interface{})
+		remoteItems, remoteOk := remote[key].([]interface{})
+		if isList && (localOk || local[key] == nil) && (remoteOk || remote[key] == nil) {
+			baseItems, _ := base[key].([]interface{})
+			items, err := mergeList(key, idKey, baseItems, localItems, remoteItems, resolve)
+			if err != nil {
+				return nil, err
+			}
+			merged[key] = items
+			continue
+		}
+
+		value, err := mergeValue(base[key], local[key], remote[key], MergeConflict{Section: key}, resolve)
+		if err != nil {
+			return nil, err
+		}
+		if value != nil {
+			merged[key] = value
+		}
+	}
+	return merged, nil
+}
@@ -0,0 +1,173 @@
+package tools
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func parseMergeConfig(t *testing.T, content string) map[string]interface{} {
+	t.Helper()
+	config := map[string]interface{}{}
+	if content == "" {
+		return config
+	}
+	if err := json.Unmarshal([]byte(content), &config); err != nil {
+		t.Fatalf("解析 %s 失败: %v", content, err)
+	}
+	return config
+}
+
+func TestThreeWayMerge(t *testing.T) {
+	tests := []struct {
+		name   string
+		base   string
+		local  string
+		remote string
+		// 冲突时使用的一侧，local或remote
+		pick      string
+		want      string
+		conflicts int
+	}{
+		{
+			name:   "只有远程修改",
+			base:   `{"theme":"dark"}`,
+			local:  `{"theme":"dark"}`,
+			remote: `{"theme":"light"}`,
+			want:   `{"theme":"light"}`,
+		},
+		{
+			name:   "只有本地修改",
+			base:   `{"theme":"dark"}`,
+			local:  `{"theme":"light"}`,
+			remote: `{"theme":"dark"}`,
+			want:   `{"theme":"light"}`,
+		},
+		{
+			name:      "两侧修改同一字段，保留本地",
+			base:      `{"theme":"dark"}`,
+			local:     `{"theme":"light"}`,
+			remote:    `{"theme":"blue"}`,
+			pick:      "local",
+			want:      `{"theme":"light"}`,
+			conflicts: 1,
+		},
+		{
+			name:      "两侧修改同一字段，使用远程",
+			base:      `{"theme":"dark"}`,
+			local:     `{"theme":"light"}`,
+			remote:    `{"theme":"blue"}`,
+			pick:      "remote",
+			want:      `{"theme":"blue"}`,
+			conflicts: 1,
+		},
+		{
+			name:   "两侧修改相同不算冲突",
+			base:   `{"theme":"dark"}`,
+			local:  `{"theme":"light"}`,
+			remote: `{"theme":"light"}`,
+			want:   `{"theme":"light"}`,
+		},
+		{
+			name:   "本地删除字段，远程未修改",
+			base:   `{"theme":"dark","lang":"zh"}`,
+			local:  `{"lang":"zh"}`,
+			remote: `{"theme":"dark","lang":"zh"}`,
+			want:   `{"lang":"zh"}`,
+		},
+		{
+			name:   "远程删除字段，本地未修改",
+			base:   `{"theme":"dark","lang":"zh"}`,
+			local:  `{"theme":"dark","lang":"zh"}`,
+			remote: `{"lang":"zh"}`,
+			want:   `{"lang":"zh"}`,
+		},
+		{
+			name:      "本地删除字段，远程修改",
+			base:      `{"theme":"dark"}`,
+			local:     `{}`,
+			remote:    `{"theme":"light"}`,
+			pick:      "local",
+			want:      `{}`,
+			conflicts: 1,
+		},
+		{
+			name:   "列表按标识合并，两侧新增都保留",
+			base:   `{"commands":[{"label":"a","value":"ls"}]}`,
+			local:  `{"commands":[{"label":"a","value":"ls"},{"label":"b","value":"pwd"}]}`,
+			remote: `{"commands":[{"label":"a","value":"ls"},{"label":"c","value":"date"}]}`,
+			want:   `{"commands":[{"label":"a","value":"ls"},{"label":"b","value":"pwd"},{"label":"c","value":"date"}]}`,
+		},
+		{
+			name:   "远程删除列表项，本地未修改",
+			base:   `{"commands":[{"label":"a","value":"ls"},{"label":"b","value":"pwd"}]}`,
+			local:  `{"commands":[{"label":"a","value":"ls"},{"label":"b","value":"pwd"}]}`,
+			remote: `{"commands":[{"label":"a","value":"ls"}]}`,
+			want:   `{"commands":[{"label":"a","value":"ls"}]}`,
+		},
+		{
+			name:   "本地删除列表项，远程未修改",
+			base:   `{"commands":[{"label":"a","value":"ls"},{"label":"b","value":"pwd"}]}`,
+			local:  `{"commands":[{"label":"b","value":"pwd"}]}`,
+			remote: `{"commands":[{"label":"a","value":"ls"},{"label":"b","value":"pwd"}]}`,
+			want:   `{"commands":[{"label":"b","value":"pwd"}]}`,
+		},
+		{
+			name:      "同一列表项两侧修改不同",
+			base:      `{"commands":[{"label":"a","value":"ls"}]}`,
+			local:     `{"commands":[{"label":"本地","value":"ls"}]}`,
+			remote:    `{"commands":[{"label":"远程","value":"ls"}]}`,
+			pick:      "remote",
+			want:      `{"commands":[{"label":"远程","value":"ls"}]}`,
+			conflicts: 1,
+		},
+		{
+			name:      "远程删除列表项，本地修改",
+			base:      `{"commands":[{"label":"a","value":"ls"}]}`,
+			local:     `{"commands":[{"label":"本地","value":"ls"}]}`,
+			remote:    `{"commands":[]}`,
+			pick:      "remote",
+			want:      `{"commands":[]}`,
+			conflicts: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conflicts := 0
+			resolve := func(conflict MergeConflict) (interface{}, error) {
+				conflicts++
+				switch tt.pick {
+				case "local":
+					return conflict.Local, nil
+				case "remote":
+					return conflict.Remote, nil
+				}
+				return nil, errors.New("不应出现冲突")
+			}
+			merged, err := ThreeWayMerge(parseMergeConfig(t, tt.base), parseMergeConfig(t, tt.local), parseMergeConfig(t, tt.remote), resolve)
+			if err != nil {
+				t.Fatalf("合并失败: %v", err)
+			}
+			if got, want := CanonicalJSON(merged), CanonicalJSON(parseMergeConfig(t, tt.want)); got != want {
+				t.Errorf("合并结果为 %s，期望 %s", got, want)
+			}
+			if conflicts != tt.conflicts {
+				t.Errorf("冲突数为 %d，期望 %d", conflicts, tt.conflicts)
+			}
+		})
+	}
+}
+
+func TestThreeWayMergeResolveError(t *testing.T) {
+	base := map[string]interface{}{"theme": "dark"}
+	local := map[string]interface{}{"theme": "light"}
+	remote := map[string]interface{}{"theme": "blue"}
+	cancel := errors.New("已取消合并")
+	_, err := ThreeWayMerge(base, local, remote, func(MergeConflict) (interface{}, error) {
+		return nil, cancel
+	})
+	if !errors.Is(err, cancel) {
+		t.Errorf("错误为 %v，期望 %v", err, cancel)
+	}
+}
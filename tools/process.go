@@ -0,0 +1,246 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// 收到退出信号后等待子进程退出的时间，超过后强制结束
+const ProcessKillTimeout = 3 * time.Second
+
+// dora启动的子进程
+type trackedProcess struct {
+	cmd  *exec.Cmd
+	done chan struct{}
+}
+
+// 子进程管理：dora启动的子进程都在这里登记
+// 收到SIGINT/SIGTERM时转发给所有子进程，超时未退出的连同其子进程一起强制结束
+var processManager = struct {
+	mu          sync.Mutex
+	processes   map[*exec.Cmd]*trackedProcess
+	subscribers map[chan<- os.Signal][]os.Signal
+	exiting     bool
+}{
+	processes:   map[*exec.Cmd]*trackedProcess{},
+	subscribers: map[chan<- os.Signal][]os.Signal{},
+}
+
+// 收到信号退出前需要执行的清理，如恢复终端
+var exitHooks = struct {
+	mu    sync.Mutex
+	id    int
+	hooks map[int]func()
+}{hooks: map[int]func(){}}
+
+// 添加退出前的清理，返回的函数移除清理
+func onSignalExit(hook func()) func() {
+	exitHooks.mu.Lock()
+	defer exitHooks.mu.Unlock()
+	exitHooks.id++
+	id := exitHooks.id
+	exitHooks.hooks[id] = hook
+	return func() {
+		exitHooks.mu.Lock()
+		delete(exitHooks.hooks, id)
+		exitHooks.mu.Unlock()
+	}
+}
+
+func runExitHooks() {
+	exitHooks.mu.Lock()
+	defer exitHooks.mu.Unlock()
+	for _, hook := range exitHooks.hooks {
+		hook()
+	}
+}
+
+// 开始处理退出信号，dora启动时调用一次
+func InitProcessManager() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		for sig := range signals {
+			if notifySubscribers(sig) {
+				continue
+			}
+			exitOnSignal(sig, signals)
+		}
+	}()
+}
+
+// 自行处理退出信号，如取消并行任务、停止watcher，返回的函数取消订阅
+// 有订阅时信号只发给订阅者，不转发给子进程，也不退出dora
+func NotifySignals(ch chan<- os.Signal, signals ...os.Signal) func() {
+	processManager.mu.Lock()
+	processManager.subscribers[ch] = signals
+	processManager.mu.Unlock()
+	return func() {
+		processManager.mu.Lock()
+		delete(processManager.subscribers, ch)
+		processManager.mu.Unlock()
+	}
+}
+
+func notifySubscribers(sig os.Signal) bool {
+	processManager.mu.Lock()
+	defer processManager.mu.Unlock()
+	notified := false
+	for ch, signals := range processManager.subscribers {
+		for _, s := range signals {
+			if s != sig {
+				continue
+			}
+			select {
+			case ch <- sig:
+			default:
+			}
+			notified = true
+		}
+	}
+	return notified
+}
+
+// 把信号转发给所有子进程，等待退出，超时的强制结束后退出dora
+// 等待期间再次收到信号时立即强制结束
+func exitOnSignal(sig os.Signal, signals <-chan os.Signal) {
+	processManager.mu.Lock()
+	processManager.exiting = true
+	processManager.mu.Unlock()
+	processes := trackedProcesses()
+	for _, p := range processes {
+		signalProcess(p.cmd, sig)
+	}
+	waitOrKill(processes, ProcessKillTimeout, signals)
+	runExitHooks()
+	code := 1
+	if s, ok := sig.(syscall.Signal); ok {
+		code = 128 + int(s)
+	}
+	os.Exit(code)
+}
+
+func trackedProcesses() []*trackedProcess {
+	processManager.mu.Lock()
+	defer processManager.mu.Unlock()
+	processes := []*trackedProcess{}
+	for _, p := range processManager.processes {
+		processes = append(processes, p)
+	}
+	return processes
+}
+
+// 等待进程退出，超过等待时间或者收到force时强制结束剩余的进程
+func waitOrKill(processes []*trackedProcess, grace time.Duration, force <-chan os.Signal) {
+	timer := time.NewTimer(grace)
+	defer timer.Stop()
+	expired := false
+	for _, p := range processes {
+		if !expired {
+			select {
+			case <-p.done:
+				killLeftovers(p.cmd)
+				continue
+			case <-timer.C:
+				expired = true
+			case <-force:
+				expired = true
+			}
+		}
+		forceKill(p, grace)
+	}
+}
+
+// 强制结束进程树，并输出被强制结束的进程
+func forceKill(p *trackedProcess, grace time.Duration) {
+	select {
+	case <-p.done:
+		return
+	default:
+	}
+	fmt.Fprintf(os.Stderr, "[强制结束]: 进程 %d 在 %s 内未退出, %s\n", p.cmd.Process.Pid, grace, processCommandLine(p.cmd))
+	if err := KillProcessTree(p.cmd); err != nil {
+		fmt.Fprintln(os.Stderr, "强制结束进程失败:", err)
+	}
+}
+
+// 通过shell执行的命令显示命令原文
+func processCommandLine(cmd *exec.Cmd) string {
+	if len(cmd.Args) == 0 {
+		return cmd.Path
+	}
+	return cmd.Args[len(cmd.Args)-1]
+}
+
+// 在独立的进程组中启动命令并登记，结束时连同其子进程一起结束
+// 启动后需要调用WaitProcess等待
+func StartProcess(cmd *exec.Cmd) error {
+	setProcessGroup(cmd)
+	return startTrackedProcess(cmd, cmd.Start)
+}
+
+// 用start启动命令并登记，如在伪终端中启动的命令、需要前台终端的shell会话
+// 启动和登记之间不会开始退出，dora正在退出时不再启动
+func startTrackedProcess(cmd *exec.Cmd, start func() error) error {
+	processManager.mu.Lock()
+	defer processManager.mu.Unlock()
+	if processManager.exiting {
+		return errors.New("dora正在退出")
+	}
+	if err := start(); err != nil {
+		return err
+	}
+	processManager.processes[cmd] = &trackedProcess{cmd: cmd, done: make(chan struct{})}
+	return nil
+}
+
+// 等待命令结束并取消登记
+// 收到退出信号后不再返回，由进程管理结束剩余的进程并退出dora
+func WaitProcess(cmd *exec.Cmd) error {
+	err := cmd.Wait()
+	processManager.mu.Lock()
+	if p, ok := processManager.processes[cmd]; ok {
+		close(p.done)
+		delete(processManager.processes, cmd)
+	}
+	exiting := processManager.exiting
+	processManager.mu.Unlock()
+	if exiting {
+		select {}
+	}
+	return err
+}
+
+// 停止命令：先结束进程组，超过等待时间仍未退出时强制结束
+// 需要另外有WaitProcess在等待命令结束
+func StopProcess(cmd *exec.Cmd, grace time.Duration) {
+	processManager.mu.Lock()
+	p, ok := processManager.processes[cmd]
+	processManager.mu.Unlock()
+	if !ok {
+		return
+	}
+	if err := TerminateProcessTree(cmd); err != nil {
+		fmt.Fprintln(os.Stderr, "停止进程失败:", err)
+	}
+	select {
+	case <-p.done:
+	case <-time.After(grace):
+		forceKill(p, grace)
+	}
+}
+
+// dora退出前结束所有还在运行的子进程
+func CleanupProcesses() {
+	processes := trackedProcesses()
+	for _, p := range processes {
+		TerminateProcessTree(p.cmd)
+	}
+	waitOrKill(processes, ProcessKillTimeout, nil)
+}
@@ -0,0 +1,55 @@
+package tools
+
+import (
+	"errors"
+	"os/exec"
+	"testing"
+)
+
+func TestStartTrackedProcess(t *testing.T) {
+	tests := []struct {
+		name     string
+		exiting  bool
+		startErr error
+		// 是否调用了start、是否登记了命令
+		wantStarted bool
+		wantTracked bool
+		wantErr     bool
+	}{
+		{"启动后登记", false, nil, true, true, false},
+		{"启动失败不登记", false, errors.New("启动失败"), true, false, true},
+		{"正在退出时不再启动", true, nil, false, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			processManager.mu.Lock()
+			processManager.exiting = tt.exiting
+			processManager.mu.Unlock()
+			cmd := exec.Command("true")
+			t.Cleanup(func() {
+				processManager.mu.Lock()
+				processManager.exiting = false
+				delete(processManager.processes, cmd)
+				processManager.mu.Unlock()
+			})
+
+			started := false
+			err := startTrackedProcess(cmd, func() error {
+				started = true
+				return tt.startErr
+			})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("startTrackedProcess() 错误为 %v，期望错误 %v", err, tt.wantErr)
+			}
+			if started != tt.wantStarted {
+				t.Errorf("调用start为 %v，期望 %v", started, tt.wantStarted)
+			}
+			processManager.mu.Lock()
+			_, tracked := processManager.processes[cmd]
+			processManager.mu.Unlock()
+			if tracked != tt.wantTracked {
+				t.Errorf("登记为 %v，期望 %v", tracked, tt.wantTracked)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+//go:build !windows
+
+package tools
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// 让子进程使用独立的进程组，方便连同其子进程一起结束，伪终端中的命令已经是独立的会话
+func setProcessGroup(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	if !cmd.SysProcAttr.Setsid {
+		cmd.SysProcAttr.Setpgid = true
+	}
+}
+
+// 独立进程组的发给整个进程组，否则只发给进程本身
+func signalProcess(cmd *exec.Cmd, sig os.Signal) error {
+	s, ok := sig.(syscall.Signal)
+	if !ok {
+		return cmd.Process.Signal(sig)
+	}
+	if attr := cmd.SysProcAttr; attr != nil && (attr.Setpgid || attr.Setsid) {
+		return syscall.Kill(-cmd.Process.Pid, s)
+	}
+	return syscall.Kill(cmd.Process.Pid, s)
+}
+
+func TerminateProcessTree(cmd *exec.Cmd) error {
+	return signalProcess(cmd, syscall.SIGTERM)
+}
+
+func KillProcessTree(cmd *exec.Cmd) error {
+	return signalProcess(cmd, syscall.SIGKILL)
+}
+
+// 进程退出后结束进程组中剩余的进程，如命令中放到后台的进程
+func killLeftovers(cmd *exec.Cmd) {
+	if attr := cmd.SysProcAttr; attr == nil || !(attr.Setpgid || attr.Setsid) {
+		return
+	}
+	if syscall.Kill(-cmd.Process.Pid, 0) != nil {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "[强制结束]: 进程组 %d 中剩余的进程, %s\n", cmd.Process.Pid, processCommandLine(cmd))
+	syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+}
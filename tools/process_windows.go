@@ -0,0 +1,28 @@
+//go:build windows
+
+package tools
+
+import (
+	"os"
+	"os/exec"
+	"strconv"
+)
+
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// windows不能给进程发信号，收到的信号都按结束进程树处理
+func signalProcess(cmd *exec.Cmd, sig os.Signal) error {
+	return TerminateProcessTree(cmd)
+}
+
+// windows没有进程组信号，使用taskkill结束进程树
+func TerminateProcessTree(cmd *exec.Cmd) error {
+	return exec.Command("taskkill", "/T", "/PID", strconv.Itoa(cmd.Process.Pid)).Run()
+}
+
+func KillProcessTree(cmd *exec.Cmd) error {
+	return exec.Command("taskkill", "/T", "/F", "/PID", strconv.Itoa(cmd.Process.Pid)).Run()
+}
+
+// taskkill已经结束了整个进程树
+func killLeftovers(cmd *exec.Cmd) {}
@@ -0,0 +1,95 @@
+//go:build !windows
+
+package tools
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/creack/pty"
+	"github.com/muesli/cancelreader"
+	terminal "golang.org/x/term"
+)
+
+// 启动命令，stdin是终端时在伪终端中执行，vim、less、ssh、REPL等交互程序都可以正常使用
+// 输出写到stdout，返回的函数等待命令结束并恢复终端
+// 伪终端中stdout和stderr合并输出
+func StartWithPty(cmd *exec.Cmd, stdout io.Writer) (func() error, error) {
+	if !terminal.IsTerminal(int(os.Stdin.Fd())) {
+		cmd.Stdin = os.Stdin
+		cmd.Stdout = stdout
+		cmd.Stderr = os.Stderr
+		if err := StartProcess(cmd); err != nil {
+			return nil, err
+		}
+		return func() error { return WaitProcess(cmd) }, nil
+	}
+
+	// 伪终端会创建新的会话，会话本身就是独立的进程组
+	if cmd.SysProcAttr != nil {
+		cmd.SysProcAttr.Setpgid = false
+	}
+	// Ctrl+C等按键由伪终端直接发给命令，dora收到的信号由进程管理转发
+	var ptmx *os.File
+	err := startTrackedProcess(cmd, func() (err error) {
+		ptmx, err = pty.Start(cmd)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 同步窗口大小，启动时先同步一次
+	pty.InheritSize(os.Stdin, ptmx)
+	resize := make(chan os.Signal, 1)
+	signal.Notify(resize, syscall.SIGWINCH)
+	go func() {
+		for range resize {
+			pty.InheritSize(os.Stdin, ptmx)
+		}
+	}()
+
+	fd := int(os.Stdin.Fd())
+	oldState, err := terminal.MakeRaw(fd)
+	if err != nil {
+		oldState = nil
+	}
+	restore := func() {
+		if oldState != nil {
+			terminal.Restore(fd, oldState)
+		}
+	}
+	removeHook := onSignalExit(restore)
+	// 命令结束后取消对stdin的读取，避免吞掉之后的输入
+	input, err := cancelreader.NewReader(os.Stdin)
+	if err == nil {
+		go io.Copy(ptmx, input)
+	}
+	copied := make(chan struct{})
+	go func() {
+		io.Copy(stdout, ptmx)
+		close(copied)
+	}()
+
+	return func() error {
+		err := WaitProcess(cmd)
+		// 后台进程仍持有伪终端时不再等待输出
+		select {
+		case <-copied:
+		case <-time.After(time.Second):
+		}
+		signal.Stop(resize)
+		close(resize)
+		if input != nil {
+			input.Cancel()
+		}
+		ptmx.Close()
+		removeHook()
+		restore()
+		return err
+	}, nil
+}
@@ -0,0 +1,20 @@
+//go:build windows
+
+package tools
+
+import (
+	"io"
+	"os"
+	"os/exec"
+)
+
+// windows不支持伪终端，命令直接使用当前的控制台
+func StartWithPty(cmd *exec.Cmd, stdout io.Writer) (func() error, error) {
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = stdout
+	cmd.Stderr = os.Stderr
+	if err := StartProcess(cmd); err != nil {
+		return nil, err
+	}
+	return func() error { return WaitProcess(cmd) }, nil
+}
@@ -1,34 +1,143 @@
 package tools
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
 )
 
-// 读取 JSON 文件并将数据解码为指定的类型
-func ReadJsonFile[T any](filePath string, out *T) error {
-	data, err := os.ReadFile(filePath)
+// 支持的配置文件格式
+const (
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+	FormatTOML = "toml"
+)
+
+// toml的表头或 key = value 形式的行
+var tomlLinePattern = regexp.MustCompile(`(?m)^\s*(\[[\w.\-"' ]+\]|[\w.\-"]+\s*=\s*\S)`)
+
+// 根据文件后缀判断配置格式，后缀无法判断时根据内容判断
+func DetectConfigFormat(filePath string, data []byte) string {
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".yaml", ".yml":
+		return FormatYAML
+	case ".toml":
+		return FormatTOML
+	}
+
+	content := bytes.TrimSpace(data)
+	switch {
+	case len(content) == 0, content[0] == '{':
+		return FormatJSON
+	case tomlLinePattern.Match(content):
+		return FormatTOML
+	case content[0] == '[':
+		return FormatJSON
+	}
+	return FormatYAML
+}
+
+// 将yaml、toml格式的配置统一转换为json，后续都按json的tag解析
+func ConfigToJSON(data []byte, format string) ([]byte, error) {
+	var content map[string]interface{}
+	switch format {
+	case FormatJSON:
+		return data, nil
+	case FormatYAML:
+		if err := yaml.Unmarshal(data, &content); err != nil {
+			return nil, fmt.Errorf("解析yaml失败: %w", err)
+		}
+	case FormatTOML:
+		if err := toml.Unmarshal(data, &content); err != nil {
+			return nil, fmt.Errorf("解析toml失败: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("不支持的配置格式: %s", format)
+	}
+	if content == nil {
+		content = map[string]interface{}{}
+	}
+	return json.Marshal(content)
+}
+
+// 将json格式的配置转换为指定格式，用于生成yaml、toml配置文件
+func JSONToConfig(data []byte, format string) ([]byte, error) {
+	if format == FormatJSON {
+		return data, nil
+	}
+	var content map[string]interface{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&content); err != nil {
+		return nil, err
+	}
+	normalizeJsonNumber(content)
+	switch format {
+	case FormatYAML:
+		return yaml.Marshal(content)
+	case FormatTOML:
+		var out bytes.Buffer
+		if err := toml.NewEncoder(&out).Encode(content); err != nil {
+			return nil, err
+		}
+		return out.Bytes(), nil
+	default:
+		return nil, fmt.Errorf("不支持的配置格式: %s", format)
+	}
+}
+
+// 将json.Number转换为整数或浮点数，避免整数被输出为500.0
+func normalizeJsonNumber(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = normalizeJsonNumber(item)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = normalizeJsonNumber(item)
+		}
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	}
+	return value
+}
+
+// 按文件格式将配置内容解码为指定的类型
+func DecodeConfig[T any](filePath string, data []byte, out *T) error {
+	jsonData, err := ConfigToJSON(data, DetectConfigFormat(filePath, data))
 	if err != nil {
 		return err
 	}
-	if err := json.Unmarshal(data, out); err != nil {
+	return json.Unmarshal(jsonData, out)
+}
+
+// 读取 JSON、YAML 或 TOML 文件并将数据解码为指定的类型
+func ReadJsonFile[T any](filePath string, out *T) error {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
 		return err
 	}
-	return nil
+	return DecodeConfig(filePath, data, out)
 }
 
-// 读取dora的json配置
+// 读取dora的配置，合并全局配置、项目的.dora/config.*和DORA_*环境变量
+// 全局配置支持.config.json、.config.yaml、.config.yml、.config.toml
 func ReadDoraJsonConfig[T any](out *T) error {
-	userHomeDir, _ := os.UserHomeDir()
-	jsonFilePath := filepath.Join(userHomeDir, "dora/.config.json")
-
-	data, err := os.ReadFile(jsonFilePath)
+	resolved, err := ResolveConfig()
 	if err != nil {
 		return err
 	}
-	if err := json.Unmarshal(data, out); err != nil {
-		return err
-	}
-	return nil
+	return DecodeResolvedConfig(resolved, out)
 }
@@ -0,0 +1,35 @@
+package tools
+
+import "testing"
+
+func TestDetectConfigFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		filePath string
+		data     string
+		want     string
+	}{
+		{"yaml后缀", "config.yaml", `{"a":1}`, FormatYAML},
+		{"yml后缀大写", "CONFIG.YML", "", FormatYAML},
+		{"toml后缀", "config.toml", "", FormatTOML},
+		{"json后缀按内容判断", "config.json", `{"a":1}`, FormatJSON},
+		{"空内容", "", "", FormatJSON},
+		{"只有空白", "", " \n\t", FormatJSON},
+		{"json对象", "", "  {\n  \"commands\": []\n}", FormatJSON},
+		{"json数组", "", `[1, 2]`, FormatJSON},
+		{"toml键值", "", "api_key = \"abc\"\n", FormatTOML},
+		{"toml表头", "", "[sync]\nbackend = \"git\"\n", FormatTOML},
+		{"toml表数组", "", "[[commands]]\nlabel = \"ls\"\n", FormatTOML},
+		{"yaml映射", "", "commands:\n  - label: ls\n", FormatYAML},
+		{"yaml列表", "", "- a\n- b\n", FormatYAML},
+		{"yaml注释", "", "# 配置\napi_key: abc\n", FormatYAML},
+		{"无后缀的文件名", ".dora", "theme: dark\n", FormatYAML},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DetectConfigFormat(tt.filePath, []byte(tt.data)); got != tt.want {
+				t.Errorf("DetectConfigFormat(%q, %q) = %s，期望 %s", tt.filePath, tt.data, got, tt.want)
+			}
+		})
+	}
+}
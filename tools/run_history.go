@@ -0,0 +1,153 @@
+package tools
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// 记录的输出最多保留的字节数，超过时保留最后的部分
+const runOutputLimit = 4096
+
+// 历史文件超过该大小时只保留后一半
+const runHistoryMaxSize = 4 << 20
+
+// 当前执行命令的来源，如 prompt、cmd、watch，写入执行记录
+var RunSource = "dora"
+
+var runHistoryMu sync.Mutex
+
+// 一次命令执行的记录
+type RunRecord struct {
+	Command  string    `json:"command"`
+	Cwd      string    `json:"cwd"`
+	GitRoot  string    `json:"git_root,omitempty"`
+	Source   string    `json:"source"`
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"`
+	ExitCode int       `json:"exit_code"`
+	Output   string    `json:"output,omitempty"`
+}
+
+// 执行记录，位于用户目录/dora/.run_history.jsonl，每行一条
+func GetRunHistoryPath() string {
+	return filepath.Join(GetDoraConfigDir(), ".run_history.jsonl")
+}
+
+// 命令返回的错误对应的退出码
+func ExitCodeOf(err error) int {
+	if err == nil {
+		return 0
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return -1
+}
+
+// 记录一次命令执行，command为替换密钥前的命令，dir为空时使用当前目录
+func RecordRun(command string, dir string, start time.Time, exitCode int, output string) {
+	if dir == "" {
+		dir = GetWorkDir()
+	}
+	if len(output) > runOutputLimit {
+		output = output[len(output)-runOutputLimit:]
+	}
+	record := RunRecord{
+		Command:  command,
+		Cwd:      dir,
+		GitRoot:  GitRootOf(dir),
+		Source:   RunSource,
+		Start:    start,
+		End:      time.Now(),
+		ExitCode: exitCode,
+		Output:   output,
+	}
+	if err := AppendRunRecord(record); err != nil {
+		log.Println("写入执行记录失败:", err)
+	}
+}
+
+// 目录所在的git根目录，不在git项目中时返回空
+// 先解析符号链接，同一个项目从不同的链接路径进入时结果相同
+func GitRootOf(dir string) string {
+	if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+		dir = resolved
+	}
+	for d := dir; ; d = filepath.Dir(d) {
+		if _, err := os.Stat(filepath.Join(d, ".git")); err == nil {
+			return d
+		}
+		if filepath.Dir(d) == d {
+			return ""
+		}
+	}
+}
+
+func AppendRunRecord(record RunRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	runHistoryMu.Lock()
+	defer runHistoryMu.Unlock()
+
+	historyPath := GetRunHistoryPath()
+	if err := os.MkdirAll(filepath.Dir(historyPath), 0755); err != nil {
+		return err
+	}
+	if fi, err := os.Stat(historyPath); err == nil && fi.Size() > runHistoryMaxSize {
+		trimRunHistory(historyPath)
+	}
+	file, err := os.OpenFile(historyPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = file.Write(append(data, '\n'))
+	return err
+}
+
+// 只保留后一半的记录
+func trimRunHistory(historyPath string) {
+	content, err := os.ReadFile(historyPath)
+	if err != nil {
+		return
+	}
+	content = content[len(content)/2:]
+	if i := bytes.IndexByte(content, '\n'); i != -1 {
+		content = content[i+1:]
+	}
+	os.WriteFile(historyPath, content, 0600)
+}
+
+// 读取所有执行记录，按时间从旧到新，格式错误的行跳过
+func ReadRunRecords() ([]RunRecord, error) {
+	records := []RunRecord{}
+	file, err := os.Open(GetRunHistoryPath())
+	if os.IsNotExist(err) {
+		return records, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var record RunRecord
+		if json.Unmarshal(scanner.Bytes(), &record) == nil {
+			records = append(records, record)
+		}
+	}
+	return records, scanner.Err()
+}
@@ -0,0 +1,51 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGitRootOf(t *testing.T) {
+	base, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	project := filepath.Join(base, "project")
+	if err := os.MkdirAll(filepath.Join(project, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(project, "src", "pkg"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(base, "other"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	// 指向项目和项目子目录的符号链接
+	if err := os.Symlink(project, filepath.Join(base, "link")); err != nil {
+		t.Skip("不支持符号链接:", err)
+	}
+	if err := os.Symlink(filepath.Join(project, "src"), filepath.Join(base, "src-link")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		dir  string
+		want string
+	}{
+		{"项目根目录", project, project},
+		{"项目子目录", filepath.Join(project, "src", "pkg"), project},
+		{"链接到项目", filepath.Join(base, "link"), project},
+		{"链接到项目的子目录", filepath.Join(base, "link", "src", "pkg"), project},
+		{"链接到子目录，链接所在目录不是项目", filepath.Join(base, "src-link", "pkg"), project},
+		{"不在git项目中", filepath.Join(base, "other"), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GitRootOf(tt.dir); got != tt.want {
+				t.Errorf("GitRootOf(%s) = %q，期望 %q", tt.dir, got, tt.want)
+			}
+		})
+	}
+}
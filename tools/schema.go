@@ -0,0 +1,350 @@
+package tools
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// 配置文件的结构描述，可校验配置内容，也可以导出为JSON Schema
+type Schema struct {
+	Title       string
+	Description string
+	// object、array、string、integer、number、boolean
+	Type       string
+	Properties map[string]*Schema
+	Required   []string
+	// object类型的值类型，为nil时不允许出现Properties以外的字段
+	AdditionalProperties *Schema
+	Items                *Schema
+	MinItems             int
+	Enum                 []string
+	// path：校验路径是否存在，相对路径相对于配置文件所在目录
+	Format string
+	// 引用Definitions中的结构，用于children这类递归结构
+	Ref         string
+	Definitions map[string]*Schema
+}
+
+// 校验错误，带有出错位置
+type SchemaError struct {
+	Path    string
+	Line    int
+	Column  int
+	Message string
+}
+
+func (e SchemaError) Error() string {
+	if e.Line == 0 {
+		return strings.TrimPrefix(fmt.Sprintf("%s: %s", e.Path, e.Message), ": ")
+	}
+	if e.Path == "" {
+		return fmt.Sprintf("%d:%d %s", e.Line, e.Column, e.Message)
+	}
+	return fmt.Sprintf("%d:%d %s: %s", e.Line, e.Column, e.Path, e.Message)
+}
+
+// 导出为 JSON Schema (draft-07)
+func (s *Schema) JSONSchema() map[string]interface{} {
+	result := s.jsonSchema()
+	result["$schema"] = "http://json-schema.org/draft-07/schema#"
+	if len(s.Definitions) > 0 {
+		definitions := map[string]interface{}{}
+		for name, definition := range s.Definitions {
+			definitions[name] = definition.jsonSchema()
+		}
+		result["definitions"] = definitions
+	}
+	return result
+}
+
+func (s *Schema) jsonSchema() map[string]interface{} {
+	result := map[string]interface{}{}
+	if s.Ref != "" {
+		result["$ref"] = "#/definitions/" + s.Ref
+		return result
+	}
+	if s.Title != "" {
+		result["title"] = s.Title
+	}
+	if s.Description != "" {
+		result["description"] = s.Description
+	}
+	if s.Type != "" {
+		result["type"] = s.Type
+	}
+	if s.Type == "object" {
+		properties := map[string]interface{}{}
+		for name, property := range s.Properties {
+			properties[name] = property.jsonSchema()
+		}
+		if len(properties) > 0 {
+			result["properties"] = properties
+		}
+		if s.AdditionalProperties != nil {
+			result["additionalProperties"] = s.AdditionalProperties.jsonSchema()
+		} else {
+			result["additionalProperties"] = false
+		}
+	}
+	if len(s.Required) > 0 {
+		result["required"] = s.Required
+	}
+	if s.Items != nil {
+		result["items"] = s.Items.jsonSchema()
+	}
+	if s.MinItems > 0 {
+		result["minItems"] = s.MinItems
+	}
+	if len(s.Enum) > 0 {
+		result["enum"] = s.Enum
+	}
+	return result
+}
+
+// 解析后的json节点，记录在原文中的位置
+type jsonNode struct {
+	kind   string
+	offset int
+	keys   []string
+	fields map[string]*jsonNode
+	items  []*jsonNode
+	value  interface{}
+}
+
+// 跳过空白和分隔符，定位到下一个值的起始位置
+func skipJsonSpace(data []byte, offset int) int {
+	for offset < len(data) && strings.ContainsRune(" \t\r\n,:", rune(data[offset])) {
+		offset++
+	}
+	return offset
+}
+
+func parseJsonNode(dec *json.Decoder, data []byte) (*jsonNode, error) {
+	node := &jsonNode{offset: skipJsonSpace(data, int(dec.InputOffset()))}
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch value := tok.(type) {
+	case json.Delim:
+		if value == '{' {
+			node.kind = "object"
+			node.fields = map[string]*jsonNode{}
+			for dec.More() {
+				keyTok, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				key := keyTok.(string)
+				child, err := parseJsonNode(dec, data)
+				if err != nil {
+					return nil, err
+				}
+				node.keys = append(node.keys, key)
+				node.fields[key] = child
+			}
+		} else {
+			node.kind = "array"
+			for dec.More() {
+				child, err := parseJsonNode(dec, data)
+				if err != nil {
+					return nil, err
+				}
+				node.items = append(node.items, child)
+			}
+		}
+		// 读取结束的 } 或 ]
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+	case string:
+		node.kind = "string"
+		node.value = value
+	case json.Number:
+		node.kind = "number"
+		node.value = value
+	case bool:
+		node.kind = "boolean"
+		node.value = value
+	case nil:
+		node.kind = "null"
+	}
+	return node, nil
+}
+
+// 将字节偏移转换为行号和列号，从1开始
+func OffsetToLineColumn(data []byte, offset int) (int, int) {
+	if offset > len(data) {
+		offset = len(data)
+	}
+	line := bytes.Count(data[:offset], []byte("\n")) + 1
+	column := offset - bytes.LastIndexByte(data[:offset], '\n')
+	return line, column
+}
+
+// 校验json内容，baseDir用于校验相对路径是否存在
+func ValidateJSON(data []byte, schema *Schema, baseDir string) []SchemaError {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	root, err := parseJsonNode(dec, data)
+	if err == nil && dec.More() {
+		err = fmt.Errorf("配置内容结束后存在多余的内容")
+	}
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		err = fmt.Errorf("配置内容不完整")
+	}
+	if err != nil {
+		offset := int(dec.InputOffset())
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			offset = int(syntaxErr.Offset)
+		}
+		line, column := OffsetToLineColumn(data, offset)
+		return []SchemaError{{Line: line, Column: column, Message: err.Error()}}
+	}
+
+	v := &schemaValidator{data: data, root: schema, baseDir: baseDir}
+	v.validate(root, schema, "")
+	sort.SliceStable(v.errors, func(i, j int) bool {
+		if v.errors[i].Line != v.errors[j].Line {
+			return v.errors[i].Line < v.errors[j].Line
+		}
+		return v.errors[i].Column < v.errors[j].Column
+	})
+	return v.errors
+}
+
+// 校验任意格式的配置内容
+// yaml、toml先转换为json再校验，转换后的位置与原文不对应，不输出行列号
+func ValidateConfig(filePath string, data []byte, schema *Schema, baseDir string) []SchemaError {
+	format := DetectConfigFormat(filePath, data)
+	if format == FormatJSON {
+		return ValidateJSON(data, schema, baseDir)
+	}
+	jsonData, err := ConfigToJSON(data, format)
+	if err != nil {
+		return []SchemaError{{Message: err.Error()}}
+	}
+	errs := ValidateJSON(jsonData, schema, baseDir)
+	for i := range errs {
+		errs[i].Line, errs[i].Column = 0, 0
+	}
+	return errs
+}
+
+// 读取并校验配置文件
+func ValidateConfigFile(filePath string, schema *Schema) ([]SchemaError, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return ValidateConfig(filePath, data, schema, filepath.Dir(filePath)), nil
+}
+
+type schemaValidator struct {
+	data    []byte
+	root    *Schema
+	baseDir string
+	errors  []SchemaError
+}
+
+func (v *schemaValidator) addError(node *jsonNode, path string, format string, args ...interface{}) {
+	line, column := OffsetToLineColumn(v.data, node.offset)
+	v.errors = append(v.errors, SchemaError{
+		Path:    path,
+		Line:    line,
+		Column:  column,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+func (v *schemaValidator) validate(node *jsonNode, schema *Schema, path string) {
+	if schema.Ref != "" {
+		definition, ok := v.root.Definitions[schema.Ref]
+		if !ok {
+			v.addError(node, path, "未定义的结构 %s", schema.Ref)
+			return
+		}
+		schema = definition
+	}
+
+	if !matchSchemaType(node, schema.Type) {
+		v.addError(node, path, "类型应为 %s，实际为 %s", schema.Type, node.kind)
+		return
+	}
+
+	switch node.kind {
+	case "object":
+		for _, key := range node.keys {
+			childPath := joinSchemaPath(path, key)
+			if property, ok := schema.Properties[key]; ok {
+				v.validate(node.fields[key], property, childPath)
+			} else if schema.AdditionalProperties != nil {
+				v.validate(node.fields[key], schema.AdditionalProperties, childPath)
+			} else {
+				v.addError(node.fields[key], childPath, "未知的字段 %s", key)
+			}
+		}
+		for _, key := range schema.Required {
+			if _, ok := node.fields[key]; !ok {
+				v.addError(node, path, "缺少必填字段 %s", key)
+			}
+		}
+	case "array":
+		if len(node.items) < schema.MinItems {
+			v.addError(node, path, "至少需要 %d 项", schema.MinItems)
+		}
+		if schema.Items != nil {
+			for i, item := range node.items {
+				v.validate(item, schema.Items, fmt.Sprintf("%s[%d]", path, i))
+			}
+		}
+	case "string":
+		value := node.value.(string)
+		if len(schema.Enum) > 0 && !SliceContains(schema.Enum, value) {
+			v.addError(node, path, "取值应为 %s 之一", strings.Join(schema.Enum, "、"))
+		}
+		if schema.Format == "path" {
+			v.validatePath(node, path, value)
+		}
+	}
+}
+
+// 校验路径是否存在，glob和!开头的反向规则不校验
+func (v *schemaValidator) validatePath(node *jsonNode, path string, value string) {
+	if value == "" || strings.HasPrefix(value, "!") || strings.ContainsAny(value, "*?[{") {
+		return
+	}
+	filePath := value
+	if !filepath.IsAbs(filePath) {
+		filePath = filepath.Join(v.baseDir, filePath)
+	}
+	if _, err := os.Stat(filePath); err != nil {
+		v.addError(node, path, "路径不存在 %s", filePath)
+	}
+}
+
+func matchSchemaType(node *jsonNode, schemaType string) bool {
+	switch schemaType {
+	case "":
+		return true
+	case "integer":
+		return node.kind == "number" && !strings.ContainsAny(string(node.value.(json.Number)), ".eE")
+	default:
+		return node.kind == schemaType
+	}
+}
+
+func joinSchemaPath(path string, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -0,0 +1,206 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// 配置中引用密钥的写法，如 ${secret:GITHUB_TOKEN}，执行命令时替换为实际的值
+var secretRefPattern = regexp.MustCompile(`\$\{secret:([A-Za-z0-9_.-]+)\}`)
+
+// 推送配置前检测的密钥类型
+var secretDetectors = []struct {
+	Kind    string
+	Name    string
+	Pattern *regexp.Regexp
+}{
+	{"AWS Access Key", "AWS_ACCESS_KEY_ID", regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"AWS Secret Key", "AWS_SECRET_ACCESS_KEY", regexp.MustCompile(`(?i)aws_secret_access_key\s*[=:]\s*["']?([A-Za-z0-9/+=]{40})`)},
+	{"GitHub Token", "GITHUB_TOKEN", regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}\b`)},
+	{"Bearer Token", "BEARER_TOKEN", regexp.MustCompile(`(?i)bearer\s+([A-Za-z0-9\-._~+/]{16,}=*)`)},
+	{"URL中的密码", "URL_PASSWORD", regexp.MustCompile(`[a-zA-Z][a-zA-Z0-9+.-]*://[^/\s:@]+:([^/\s@]+)@`)},
+}
+
+// 检测到的密钥
+type SecretFinding struct {
+	// 所在的配置字段，如 commands[2].value
+	Path  string
+	Kind  string
+	Name  string
+	Value string
+}
+
+// 本地密钥文件，位于用户目录/dora/.secrets.json，只有当前用户可读写，不参与同步
+func GetSecretsPath() string {
+	return filepath.Join(GetDoraConfigDir(), ".secrets.json")
+}
+
+func LoadSecrets() (map[string]string, error) {
+	secrets := map[string]string{}
+	content, err := os.ReadFile(GetSecretsPath())
+	if os.IsNotExist(err) {
+		return secrets, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(content, &secrets); err != nil {
+		return nil, fmt.Errorf("密钥文件格式错误: %w", err)
+	}
+	return secrets, nil
+}
+
+func SaveSecrets(secrets map[string]string) error {
+	content, err := json.MarshalIndent(secrets, "", "    ")
+	if err != nil {
+		return err
+	}
+	secretsPath := GetSecretsPath()
+	if err := os.MkdirAll(filepath.Dir(secretsPath), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(secretsPath, content, 0600); err != nil {
+		return err
+	}
+	return os.Chmod(secretsPath, 0600)
+}
+
+// 替换文本中的 ${secret:NAME} 引用，同时返回用到的密钥值，用于输出时隐藏
+func ResolveSecrets(text string) (string, []string, error) {
+	if !secretRefPattern.MatchString(text) {
+		return text, nil, nil
+	}
+	secrets, err := LoadSecrets()
+	if err != nil {
+		return "", nil, err
+	}
+	values := []string{}
+	missing := []string{}
+	resolved := secretRefPattern.ReplaceAllStringFunc(text, func(ref string) string {
+		name := secretRefPattern.FindStringSubmatch(ref)[1]
+		value, ok := secrets[name]
+		if !ok {
+			missing = append(missing, name)
+			return ref
+		}
+		values = append(values, value)
+		return value
+	})
+	if len(missing) > 0 {
+		return "", nil, fmt.Errorf("未找到密钥 %s，可执行 dora secret set %s 添加", strings.Join(missing, "、"), missing[0])
+	}
+	return resolved, values, nil
+}
+
+// 将文本中的密钥值替换为 ******
+func MaskSecrets(text string, values []string) string {
+	for _, value := range values {
+		if value != "" {
+			text = strings.ReplaceAll(text, value, "******")
+		}
+	}
+	return text
+}
+
+// 检测配置中的密钥，已经写成 ${secret:NAME} 引用的不算
+func DetectSecrets(config interface{}) []SecretFinding {
+	findings := []SecretFinding{}
+	walkConfigStrings(config, "", func(path string, value string) {
+		for _, detector := range secretDetectors {
+			for _, match := range detector.Pattern.FindAllStringSubmatch(value, -1) {
+				secret := match[0]
+				if len(match) > 1 {
+					secret = match[1]
+				}
+				if secretRefPattern.MatchString(secret) {
+					continue
+				}
+				findings = append(findings, SecretFinding{Path: path, Kind: detector.Kind, Name: detector.Name, Value: secret})
+			}
+		}
+	})
+	return findings
+}
+
+func walkConfigStrings(value interface{}, path string, visit func(path string, value string)) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			walkConfigStrings(v[key], joinSchemaPath(path, key), visit)
+		}
+	case []interface{}:
+		for i, item := range v {
+			walkConfigStrings(item, fmt.Sprintf("%s[%d]", path, i), visit)
+		}
+	case string:
+		visit(path, v)
+	}
+}
+
+// 将检测到的密钥替换为 ${secret:NAME} 引用，密钥值保存到本地密钥文件
+// 返回替换后的配置，原配置不修改
+func StripSecrets(config map[string]interface{}, findings []SecretFinding) (map[string]interface{}, error) {
+	secrets, err := LoadSecrets()
+	if err != nil {
+		return nil, err
+	}
+	replacer := []string{}
+	for _, finding := range findings {
+		name := secretName(secrets, finding.Name, finding.Value)
+		secrets[name] = finding.Value
+		replacer = append(replacer, finding.Value, "${secret:"+name+"}")
+	}
+	if err := SaveSecrets(secrets); err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+	stripped := map[string]interface{}{}
+	err = json.Unmarshal(data, &stripped)
+	if err != nil {
+		return nil, err
+	}
+	r := strings.NewReplacer(replacer...)
+	return replaceConfigStrings(stripped, r.Replace).(map[string]interface{}), nil
+}
+
+// 已保存相同的值时复用名称，否则在名称后加序号避免覆盖
+func secretName(secrets map[string]string, name string, value string) string {
+	candidate := name
+	for i := 2; ; i++ {
+		existing, ok := secrets[candidate]
+		if !ok || existing == value {
+			return candidate
+		}
+		candidate = fmt.Sprintf("%s_%d", name, i)
+	}
+}
+
+func replaceConfigStrings(value interface{}, replace func(string) string) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = replaceConfigStrings(item, replace)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = replaceConfigStrings(item, replace)
+		}
+	case string:
+		return replace(v)
+	}
+	return value
+}
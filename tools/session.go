@@ -0,0 +1,251 @@
+//go:build !windows
+
+package tools
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/creack/pty"
+	"github.com/muesli/cancelreader"
+	terminal "golang.org/x/term"
+)
+
+// 会话中的shell已退出
+var ErrSessionClosed = errors.New("shell会话已结束")
+
+// 命令执行完成后shell输出到伪终端的标记，读到标记说明命令的输出已全部转发
+const sessionDoneMark = "\x1b]dora-done\x07"
+
+// 会话初始化：展开别名，开启作业控制，Ctrl+C只结束正在执行的命令
+// 执行完成后清除命令变量，输出完成标记，把退出码和目录写到fd 3
+const sessionInitScript = `shopt -s expand_aliases 2>/dev/null
+set -m
+trap : INT
+__dora_status() { unset __dora_cmd; printf '\033]dora-done\007'; printf '%s\n%s\n' "$1" "$PWD" >&3; }
+__dora_status 0
+`
+
+// 常驻的shell会话，export、alias、source、函数和cd在多条命令之间保留
+// shell运行在伪终端中，有自己的tty和作业控制，命令从fd 4的管道读取
+type ShellSession struct {
+	mu     sync.Mutex
+	cmd    *exec.Cmd
+	ptmx   *os.File
+	stdin  io.WriteCloser
+	status *bufio.Reader
+	done   chan struct{}
+	dir    string
+	closed bool
+}
+
+// 启动shell会话，没有bash或者没有终端时返回错误
+func NewShellSession() (*ShellSession, error) {
+	if !terminal.IsTerminal(int(os.Stdin.Fd())) {
+		return nil, fmt.Errorf("没有可用的终端")
+	}
+	shell, err := exec.LookPath("bash")
+	if err != nil {
+		return nil, err
+	}
+
+	statusReader, statusWriter, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	commandReader, commandWriter, err := os.Pipe()
+	if err != nil {
+		statusReader.Close()
+		statusWriter.Close()
+		return nil, err
+	}
+	cmd := exec.Command(shell, "--noprofile", "--norc", "/dev/fd/4")
+	cmd.ExtraFiles = []*os.File{statusWriter, commandReader}
+	// 伪终端会创建新的会话，shell作为会话首进程管理命令的进程组
+	var ptmx *os.File
+	err = startTrackedProcess(cmd, func() (err error) {
+		ptmx, err = pty.Start(cmd)
+		return err
+	})
+	statusWriter.Close()
+	commandReader.Close()
+	if err != nil {
+		statusReader.Close()
+		commandWriter.Close()
+		return nil, err
+	}
+
+	s := &ShellSession{
+		cmd:    cmd,
+		ptmx:   ptmx,
+		stdin:  commandWriter,
+		status: bufio.NewReader(statusReader),
+		done:   make(chan struct{}, 1),
+	}
+	go s.copyOutput()
+	if _, err := io.WriteString(commandWriter, sessionInitScript); err != nil {
+		s.Close()
+		return nil, err
+	}
+	if _, err := s.readStatus(); err != nil {
+		s.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// 将伪终端的输出转发到stdout，去掉完成标记并通知命令的输出已转发完
+func (s *ShellSession) copyOutput() {
+	mark := []byte(sessionDoneMark)
+	pending := []byte{}
+	buf := make([]byte, 4096)
+	for {
+		n, err := s.ptmx.Read(buf)
+		if n > 0 {
+			pending = append(pending, buf[:n]...)
+			for {
+				i := bytes.Index(pending, mark)
+				if i == -1 {
+					break
+				}
+				os.Stdout.Write(pending[:i])
+				pending = pending[i+len(mark):]
+				select {
+				case s.done <- struct{}{}:
+				default:
+				}
+			}
+			// 末尾可能是被截断的标记，留到下次读取
+			keep := 0
+			for k := len(mark) - 1; k > 0; k-- {
+				if bytes.HasSuffix(pending, mark[:k]) {
+					keep = k
+					break
+				}
+			}
+			os.Stdout.Write(pending[:len(pending)-keep])
+			pending = append([]byte{}, pending[len(pending)-keep:]...)
+		}
+		if err != nil {
+			os.Stdout.Write(pending)
+			return
+		}
+	}
+}
+
+// 会话当前所在的目录
+func (s *ShellSession) Dir() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.dir
+}
+
+// 读取一条命令执行后的退出码和目录
+func (s *ShellSession) readStatus() (int, error) {
+	code, err := s.status.ReadString('\n')
+	if err != nil {
+		return -1, ErrSessionClosed
+	}
+	dir, err := s.status.ReadString('\n')
+	if err != nil {
+		return -1, ErrSessionClosed
+	}
+	exitCode, _ := strconv.Atoi(strings.TrimSpace(code))
+	s.dir = strings.TrimSuffix(dir, "\n")
+	// 等待命令的输出转发完，shell的输出被重定向时不会有标记
+	select {
+	case <-s.done:
+	case <-time.After(time.Second):
+	}
+	return exitCode, nil
+}
+
+// 在会话中执行一条命令，等待执行完成，dora的工作目录跟随会话的目录
+// 命令中执行了exit时返回ErrSessionClosed
+func (s *ShellSession) Run(command string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return -1, ErrSessionClosed
+	}
+
+	// 执行期间dora忽略Ctrl+C，按键由伪终端发给前台的命令
+	signals := make(chan os.Signal, 1)
+	defer NotifySignals(signals, os.Interrupt)()
+	defer s.attachTerminal()()
+	// 丢弃上一条命令超时后才到达的完成标记
+	select {
+	case <-s.done:
+	default:
+	}
+
+	// 命令整体作为字符串传给eval，执行完成后__dora_status清除__dora_cmd
+	// 分行写入，Ctrl+C中断命令后shell会丢弃当前行剩余的部分
+	script := fmt.Sprintf("__dora_cmd=%s\neval \"$__dora_cmd\"\n__dora_status $?\n", ShellQuote(command))
+	if _, err := io.WriteString(s.stdin, script); err != nil {
+		s.closed = true
+		return -1, ErrSessionClosed
+	}
+	exitCode, err := s.readStatus()
+	if err != nil {
+		s.closed = true
+		WaitProcess(s.cmd)
+		s.ptmx.Close()
+		return -1, err
+	}
+	if err := os.Chdir(s.dir); err != nil {
+		fmt.Printf("切换到目录 %s 失败: %v\n", s.dir, err)
+	}
+	return exitCode, nil
+}
+
+// 命令执行期间将终端切换为raw模式，输入转发到伪终端，返回恢复终端的函数
+func (s *ShellSession) attachTerminal() func() {
+	pty.InheritSize(os.Stdin, s.ptmx)
+	fd := int(os.Stdin.Fd())
+	oldState, err := terminal.MakeRaw(fd)
+	if err != nil {
+		oldState = nil
+	}
+	restore := func() {
+		if oldState != nil {
+			terminal.Restore(fd, oldState)
+		}
+	}
+	removeHook := onSignalExit(restore)
+	// 命令结束后取消对stdin的读取，避免吞掉之后的输入
+	input, err := cancelreader.NewReader(os.Stdin)
+	if err == nil {
+		go io.Copy(s.ptmx, input)
+	}
+	return func() {
+		if input != nil {
+			input.Cancel()
+		}
+		removeHook()
+		restore()
+	}
+}
+
+// 结束会话
+func (s *ShellSession) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	s.stdin.Close()
+	err := WaitProcess(s.cmd)
+	s.ptmx.Close()
+	return err
+}
@@ -0,0 +1,26 @@
+//go:build windows
+
+package tools
+
+import "errors"
+
+var ErrSessionClosed = errors.New("shell会话已结束")
+
+// windows不支持常驻的shell会话，交互命令行每条命令单独执行
+type ShellSession struct{}
+
+func NewShellSession() (*ShellSession, error) {
+	return nil, errors.New("windows不支持shell会话")
+}
+
+func (s *ShellSession) Dir() string {
+	return ""
+}
+
+func (s *ShellSession) Run(command string) (int, error) {
+	return -1, ErrSessionClosed
+}
+
+func (s *ShellSession) Close() error {
+	return nil
+}
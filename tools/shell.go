@@ -0,0 +1,32 @@
+package tools
+
+import (
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// 使用系统shell执行命令，支持管道、&&、引号和环境变量赋值
+// windows使用cmd /C，其他系统优先使用bash，不存在时使用sh
+func NewShellCommand(command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.Command("cmd", "/C", command)
+	}
+	shell := "bash"
+	if _, err := exec.LookPath(shell); err != nil {
+		shell = "sh"
+	}
+	return exec.Command(shell, "-c", command)
+}
+
+// 将参数转义为shell中的单个参数
+// 含特殊字符时整体用单引号包裹，内部的单引号单独转义
+func ShellQuote(arg string) string {
+	if runtime.GOOS == "windows" {
+		return `"` + strings.ReplaceAll(arg, `"`, `\"`) + `"`
+	}
+	if arg != "" && !strings.ContainsAny(arg, " \t\n'\"\\$`!*?[]{}()<>|&;#~") {
+		return arg
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
@@ -0,0 +1,136 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// shell配置中的别名或函数
+type ShellAlias struct {
+	Name    string
+	Command string
+	// alias 或 function
+	Kind   string
+	Source string
+}
+
+// 读取的shell配置文件
+var shellRcFiles = []string{".bashrc", ".bash_aliases", ".bash_profile", ".zshrc", ".zprofile", ".profile"}
+
+var (
+	// alias gst='git status'，支持 alias -g 等选项
+	aliasPattern = regexp.MustCompile(`^alias\s+(?:-\w+\s+)*([^\s=]+)=(.*)$`)
+	// gco() { 或 function gco { 或 function gco() {
+	functionPattern = regexp.MustCompile(`^(?:function\s+)?([A-Za-z_][A-Za-z0-9_.:-]*)\s*(?:\(\s*\))?\s*\{(.*)$`)
+	// 函数体中包含流程控制时不算简单函数
+	controlPattern = regexp.MustCompile(`(^|[\s;])(if|then|for|while|until|case|select|local|return)(\s|;|$)`)
+	// 函数的位置参数转换为模板参数
+	positionalPattern = regexp.MustCompile(`"?\$\{?([1-9])\}?"?`)
+	allArgsPattern    = regexp.MustCompile(`\s*"?\$[@*]"?`)
+	// ${1}、${HOME}这类变量的括号不是函数体的括号
+	braceVarPattern = regexp.MustCompile(`\$\{[^{}]*\}`)
+)
+
+// 读取用户目录下shell配置文件中的别名和简单函数，同名的以后定义的为准
+func ReadShellAliases() []ShellAlias {
+	aliases := []ShellAlias{}
+	index := map[string]int{}
+	for _, name := range shellRcFiles {
+		filePath := filepath.Join(GetUserHomePath(), name)
+		content, err := os.ReadFile(filePath)
+		if err != nil {
+			continue
+		}
+		for _, alias := range ParseShellAliases(string(content)) {
+			alias.Source = filePath
+			if i, ok := index[alias.Name]; ok {
+				aliases[i] = alias
+				continue
+			}
+			index[alias.Name] = len(aliases)
+			aliases = append(aliases, alias)
+		}
+	}
+	return aliases
+}
+
+// 解析shell脚本中的alias和简单函数
+// 简单函数为不含流程控制、最多3行的函数，多行用&&连接，$1等参数转换为{{arg1}}模板参数
+func ParseShellAliases(content string) []ShellAlias {
+	aliases := []ShellAlias{}
+	lines := strings.Split(content, "\n")
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if match := aliasPattern.FindStringSubmatch(line); match != nil {
+			// zsh的全局别名如 G='| grep' 不能单独执行
+			if command := unquoteShellValue(match[2]); command != "" && !strings.HasPrefix(command, "|") {
+				aliases = append(aliases, ShellAlias{Name: match[1], Command: command, Kind: "alias"})
+			}
+			continue
+		}
+		match := functionPattern.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		// 函数体到单独的}或以}结尾的行为止
+		body := []string{}
+		rest := strings.TrimSpace(match[2])
+		closed := false
+		for {
+			if strings.HasSuffix(braceVarPattern.ReplaceAllString(rest, ""), "}") {
+				rest = strings.TrimSpace(strings.TrimSuffix(rest, "}"))
+				closed = true
+			}
+			for _, part := range strings.Split(rest, ";") {
+				if part = strings.TrimSpace(part); part != "" && !strings.HasPrefix(part, "#") {
+					body = append(body, part)
+				}
+			}
+			if closed || i+1 >= len(lines) {
+				break
+			}
+			i++
+			rest = strings.TrimSpace(lines[i])
+		}
+		if command := simpleFunctionCommand(body); closed && command != "" {
+			aliases = append(aliases, ShellAlias{Name: match[1], Command: command, Kind: "function"})
+		}
+	}
+	return aliases
+}
+
+func simpleFunctionCommand(body []string) string {
+	if len(body) == 0 || len(body) > 3 {
+		return ""
+	}
+	for _, line := range body {
+		if controlPattern.MatchString(line) || strings.ContainsAny(braceVarPattern.ReplaceAllString(line, ""), "{}") {
+			return ""
+		}
+	}
+	command := strings.Join(body, " && ")
+	command = allArgsPattern.ReplaceAllString(command, "")
+	return positionalPattern.ReplaceAllString(command, "{{arg$1}}")
+}
+
+// 去掉alias值外层的引号，'...'\”...' 的写法还原为单引号
+func unquoteShellValue(value string) string {
+	value = strings.TrimSpace(value)
+	if i := strings.Index(value, " #"); i != -1 && !strings.HasPrefix(value, "'") && !strings.HasPrefix(value, `"`) {
+		value = strings.TrimSpace(value[:i])
+	}
+	if len(value) >= 2 {
+		quote := value[0]
+		if (quote == '\'' || quote == '"') && value[len(value)-1] == quote {
+			value = value[1 : len(value)-1]
+			if quote == '\'' {
+				value = strings.ReplaceAll(value, `'\''`, "'")
+			} else {
+				value = strings.ReplaceAll(value, `\"`, `"`)
+			}
+		}
+	}
+	return strings.TrimSpace(value)
+}
@@ -0,0 +1,80 @@
+package tools
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseShellAliases(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []ShellAlias
+	}{
+		{
+			name:    "单引号和双引号的alias",
+			content: "alias gst='git status'\nalias ll=\"ls -la\"",
+			want: []ShellAlias{
+				{Name: "gst", Command: "git status", Kind: "alias"},
+				{Name: "ll", Command: "ls -la", Kind: "alias"},
+			},
+		},
+		{
+			name:    "没有引号和行尾注释",
+			content: "alias k=kubectl # 常用",
+			want:    []ShellAlias{{Name: "k", Command: "kubectl", Kind: "alias"}},
+		},
+		{
+			name:    "单引号中的转义",
+			content: `alias hi='echo '\''hi'\'''`,
+			want:    []ShellAlias{{Name: "hi", Command: "echo 'hi'", Kind: "alias"}},
+		},
+		{
+			name:    "zsh的选项和全局别名",
+			content: "alias -g G='| grep'\nalias -s md=code",
+			want:    []ShellAlias{{Name: "md", Command: "code", Kind: "alias"}},
+		},
+		{
+			name:    "单行函数，参数转换为模板参数",
+			content: `gco() { git checkout "$1"; }`,
+			want:    []ShellAlias{{Name: "gco", Command: "git checkout {{arg1}}", Kind: "function"}},
+		},
+		{
+			name:    "多行函数用&&连接，去掉$@",
+			content: "function mkcd {\n  mkdir -p ${1}\n  cd $1 \"$@\"\n}",
+			want:    []ShellAlias{{Name: "mkcd", Command: "mkdir -p {{arg1}} && cd {{arg1}}", Kind: "function"}},
+		},
+		{
+			name:    "函数中的${VAR}",
+			content: "home() { cd ${HOME}; }",
+			want:    []ShellAlias{{Name: "home", Command: "cd ${HOME}", Kind: "function"}},
+		},
+		{
+			name:    "包含流程控制的函数跳过",
+			content: "up() {\n  if [ -n \"$1\" ]; then cd ..; fi\n}\nalias a=b",
+			want:    []ShellAlias{{Name: "a", Command: "b", Kind: "alias"}},
+		},
+		{
+			name:    "超过3行的函数跳过",
+			content: "f() {\n  a\n  b\n  c\n  d\n}",
+			want:    []ShellAlias{},
+		},
+		{
+			name:    "没有结束的函数跳过",
+			content: "f() {\n  echo hi",
+			want:    []ShellAlias{},
+		},
+		{
+			name:    "忽略其他内容",
+			content: "export PATH=$PATH:/bin\n# alias x='y'\nsource ~/.profile",
+			want:    []ShellAlias{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseShellAliases(tt.content); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseShellAliases() = %+v，期望 %+v", got, tt.want)
+			}
+		})
+	}
+}
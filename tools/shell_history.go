@@ -0,0 +1,104 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// 读取shell的历史命令，按从新到旧去重，最多返回limit条
+// 依次读取 $HISTFILE、~/.zsh_history、~/.bash_history
+func ReadShellHistory(limit int) []string {
+	commands := []string{}
+	seen := map[string]bool{}
+	for _, lines := range readShellHistoryFiles() {
+		for i := len(lines) - 1; i >= 0 && len(commands) < limit; i-- {
+			if seen[lines[i]] {
+				continue
+			}
+			seen[lines[i]] = true
+			commands = append(commands, lines[i])
+		}
+	}
+	return commands
+}
+
+// 每个历史文件中的命令，从旧到新
+func readShellHistoryFiles() [][]string {
+	homeDir := GetUserHomePath()
+	files := []string{os.Getenv("HISTFILE"), filepath.Join(homeDir, ".zsh_history"), filepath.Join(homeDir, ".bash_history")}
+
+	result := [][]string{}
+	read := map[string]bool{}
+	for _, file := range files {
+		if file == "" || read[file] {
+			continue
+		}
+		read[file] = true
+		content, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		commands := []string{}
+		for _, line := range strings.Split(string(content), "\n") {
+			if command := parseShellHistoryLine(line); command != "" {
+				commands = append(commands, command)
+			}
+		}
+		result = append(result, commands)
+	}
+	return result
+}
+
+// 执行次数最多的历史命令
+type CommandCount struct {
+	Command string
+	Count   int
+}
+
+// 不值得收录的简单命令
+var trivialCommands = []string{"ls", "ll", "la", "cd", "pwd", "clear", "exit", "history", "cls", "..", "q"}
+
+// 统计历史命令的执行次数，返回次数最多的top条，跳过ls、cd等简单命令
+func TopShellCommands(top int) []CommandCount {
+	counts := map[string]int{}
+	order := []string{}
+	for _, lines := range readShellHistoryFiles() {
+		for _, command := range lines {
+			parts := strings.Fields(command)
+			if len(parts) == 0 || (len(parts) == 1 && SliceContains(trivialCommands, parts[0])) || parts[0] == "cd" {
+				continue
+			}
+			if counts[command] == 0 {
+				order = append(order, command)
+			}
+			counts[command]++
+		}
+	}
+	result := make([]CommandCount, 0, len(order))
+	for _, command := range order {
+		result = append(result, CommandCount{Command: command, Count: counts[command]})
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Count > result[j].Count
+	})
+	if top > 0 && len(result) > top {
+		result = result[:top]
+	}
+	return result
+}
+
+// zsh的扩展格式为 : 1700000000:0;git status，多行命令只保留单行的
+func parseShellHistoryLine(line string) string {
+	line = strings.TrimSpace(line)
+	if strings.HasPrefix(line, ": ") {
+		if i := strings.Index(line, ";"); i != -1 {
+			line = line[i+1:]
+		}
+	}
+	if strings.HasPrefix(line, "#") || strings.HasSuffix(line, "\\") {
+		return ""
+	}
+	return strings.TrimSpace(line)
+}
@@ -0,0 +1,124 @@
+package tools
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// 同步后端的类型
+const (
+	SyncBackendHTTP = "http"
+	SyncBackendGit  = "git"
+	SyncBackendDir  = "dir"
+)
+
+// 同步配置的默认文件名，用于git仓库和共享目录
+const defaultSyncFileName = "dora.config.json"
+
+// 远程还没有配置文件
+var ErrSyncNotFound = errors.New("远程配置不存在")
+
+// 配置中的sync设置
+type SyncSettings struct {
+	// http、git、dir
+	Backend string `json:"backend"`
+	// http：配置文件的https地址
+	Url string `json:"url"`
+	// http：Bearer认证的token
+	Token string `json:"token"`
+	// git：仓库地址
+	Repo string `json:"repo"`
+	// git：分支，默认main
+	Branch string `json:"branch"`
+	// git：仓库中的文件路径；dir：共享目录或文件路径
+	Path string `json:"path"`
+}
+
+// 配置同步后端
+type SyncBackend interface {
+	// 后端的描述，用于输出
+	Name() string
+	// 拉取远程的配置内容
+	Pull() ([]byte, error)
+	// 推送本地的配置内容
+	Push(content []byte) error
+}
+
+// 根据设置创建同步后端
+func NewSyncBackend(settings SyncSettings) (SyncBackend, error) {
+	switch settings.Backend {
+	case SyncBackendHTTP:
+		return newHttpSyncBackend(settings)
+	case SyncBackendGit:
+		return newGitSyncBackend(settings)
+	case SyncBackendDir:
+		return newDirSyncBackend(settings)
+	case "":
+		return nil, fmt.Errorf("未设置同步方式，请在配置的sync.backend中设置http、git或dir")
+	default:
+		return nil, fmt.Errorf("不支持的同步方式: %s", settings.Backend)
+	}
+}
+
+// 本地目录或共享盘
+type dirSyncBackend struct {
+	filePath string
+}
+
+func newDirSyncBackend(settings SyncSettings) (SyncBackend, error) {
+	if settings.Path == "" {
+		return nil, fmt.Errorf("dir同步需要设置sync.path")
+	}
+	filePath := settings.Path
+	if fi, err := os.Stat(filePath); err == nil && fi.IsDir() {
+		filePath = filepath.Join(filePath, defaultSyncFileName)
+	}
+	return &dirSyncBackend{filePath: filePath}, nil
+}
+
+func (b *dirSyncBackend) Name() string {
+	return "dir " + b.filePath
+}
+
+func (b *dirSyncBackend) Pull() ([]byte, error) {
+	content, err := os.ReadFile(b.filePath)
+	if os.IsNotExist(err) {
+		return nil, ErrSyncNotFound
+	}
+	return content, err
+}
+
+func (b *dirSyncBackend) Push(content []byte) error {
+	if err := os.MkdirAll(filepath.Dir(b.filePath), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(b.filePath, content, 0644)
+}
+
+// 上次同步时的配置内容，作为三方合并的共同祖先，每个同步后端单独记录
+func syncBasePath(backend SyncBackend) string {
+	hash := sha1.Sum([]byte(backend.Name()))
+	return filepath.Join(GetDoraConfigDir(), ".sync", "base_"+hex.EncodeToString(hash[:])[:12]+".json")
+}
+
+// 读取上次同步的内容，没有同步过时返回nil
+func LoadSyncBase(backend SyncBackend) []byte {
+	content, err := os.ReadFile(syncBasePath(backend))
+	if err != nil {
+		return nil
+	}
+	return content
+}
+
+// 记录本次同步的内容
+func SaveSyncBase(backend SyncBackend, content []byte) error {
+	basePath := syncBasePath(backend)
+	if err := os.MkdirAll(filepath.Dir(basePath), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(basePath, content, 0644)
+}
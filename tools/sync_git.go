@@ -0,0 +1,161 @@
+package tools
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// git仓库：在用户目录/dora/.sync下维护仓库的克隆，每次先更新到远程的最新内容，推送时commit并push
+type gitSyncBackend struct {
+	repo     string
+	branch   string
+	path     string
+	cloneDir string
+}
+
+func newGitSyncBackend(settings SyncSettings) (SyncBackend, error) {
+	if settings.Repo == "" {
+		return nil, fmt.Errorf("git同步需要设置sync.repo")
+	}
+	branch := settings.Branch
+	if branch == "" {
+		branch = "main"
+	}
+	path := settings.Path
+	if path == "" {
+		path = defaultSyncFileName
+	}
+	path, err := cleanRepoPath(path)
+	if err != nil {
+		return nil, err
+	}
+	hash := sha1.Sum([]byte(settings.Repo))
+	cloneDir := filepath.Join(GetUserHomePath(), "dora", ".sync", hex.EncodeToString(hash[:])[:12])
+	return &gitSyncBackend{
+		repo:     settings.Repo,
+		branch:   branch,
+		path:     path,
+		cloneDir: cloneDir,
+	}, nil
+}
+
+// 仓库中的文件路径只能是仓库内的相对路径，不能指向仓库外或.git目录
+func cleanRepoPath(path string) (string, error) {
+	cleaned := filepath.ToSlash(filepath.Clean(filepath.FromSlash(path)))
+	first, _, _ := strings.Cut(cleaned, "/")
+	if filepath.IsAbs(path) || filepath.VolumeName(path) != "" || strings.HasPrefix(path, "/") || strings.HasPrefix(path, "\\") ||
+		cleaned == "." || first == ".." || first == ".git" {
+		return "", fmt.Errorf("sync.path 应为仓库中的相对路径，不能是绝对路径、指向仓库外或.git目录: %s", path)
+	}
+	return cleaned, nil
+}
+
+func (b *gitSyncBackend) Name() string {
+	return fmt.Sprintf("git %s#%s:%s", b.repo, b.branch, b.path)
+}
+
+// 在仓库目录下执行git命令
+func (b *gitSyncBackend) git(args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-C", b.cloneDir}, args...)...)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("git %s 失败: %v\n%s", strings.Join(args, " "), err, out)
+	}
+	return string(out), nil
+}
+
+// 更新到远程分支的最新内容，丢弃本地未推送的提交和修改
+// 远程还没有该分支时（如空仓库）切换到空的分支，推送时创建
+func (b *gitSyncBackend) update() error {
+	if _, err := os.Stat(filepath.Join(b.cloneDir, ".git")); err != nil {
+		if err := os.MkdirAll(b.cloneDir, 0755); err != nil {
+			return err
+		}
+		if _, err := b.git("init", "-q"); err != nil {
+			return err
+		}
+		if _, err := b.git("remote", "add", "origin", b.repo); err != nil {
+			return err
+		}
+	}
+
+	heads, err := b.git("ls-remote", "--heads", "origin", b.branch)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(heads) == "" {
+		b.git("update-ref", "-d", "refs/heads/"+b.branch)
+		for _, args := range [][]string{
+			{"symbolic-ref", "HEAD", "refs/heads/" + b.branch},
+			{"read-tree", "--empty"},
+			{"clean", "-fdxq"},
+		} {
+			if _, err := b.git(args...); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	remoteRef := "refs/remotes/origin/" + b.branch
+	for _, args := range [][]string{
+		{"fetch", "-q", "origin", "+refs/heads/" + b.branch + ":" + remoteRef},
+		{"checkout", "-q", "-f", "-B", b.branch, remoteRef},
+		{"reset", "-q", "--hard", remoteRef},
+		{"clean", "-fdxq"},
+	} {
+		if _, err := b.git(args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *gitSyncBackend) Pull() ([]byte, error) {
+	if err := b.update(); err != nil {
+		return nil, err
+	}
+	content, err := os.ReadFile(filepath.Join(b.cloneDir, b.path))
+	if os.IsNotExist(err) {
+		return nil, ErrSyncNotFound
+	}
+	return content, err
+}
+
+func (b *gitSyncBackend) Push(content []byte) error {
+	if err := b.update(); err != nil {
+		return err
+	}
+	filePath := filepath.Join(b.cloneDir, b.path)
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filePath, content, 0644); err != nil {
+		return err
+	}
+
+	status, err := b.git("status", "--porcelain", "--", b.path)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(status) == "" {
+		return nil
+	}
+	if _, err := b.git("add", "--", b.path); err != nil {
+		return err
+	}
+	if _, err := b.git("commit", "-m", "update dora config", "--", b.path); err != nil {
+		return err
+	}
+	if _, err := b.git("push", "origin", "HEAD:refs/heads/"+b.branch); err != nil {
+		// 推送被拒绝时丢弃本地的提交，下次重新从远程开始
+		b.update()
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package tools
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// 通用的https接口：GET拉取配置内容，PUT上传配置内容，使用Bearer认证
+type httpSyncBackend struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+func newHttpSyncBackend(settings SyncSettings) (SyncBackend, error) {
+	if settings.Url == "" {
+		return nil, fmt.Errorf("http同步需要设置sync.url")
+	}
+	parsed, err := url.Parse(settings.Url)
+	if err != nil {
+		return nil, fmt.Errorf("sync.url格式错误: %v", err)
+	}
+	// 除本机调试外，不允许明文传输配置和token
+	isLocal := parsed.Hostname() == "localhost" || parsed.Hostname() == "127.0.0.1"
+	if parsed.Scheme != "https" && !(parsed.Scheme == "http" && isLocal) {
+		return nil, fmt.Errorf("sync.url必须使用https: %s", settings.Url)
+	}
+	return &httpSyncBackend{
+		url:    settings.Url,
+		token:  settings.Token,
+		client: &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+func (b *httpSyncBackend) Name() string {
+	return "http " + b.url
+}
+
+func (b *httpSyncBackend) do(method string, body []byte) ([]byte, error) {
+	req, err := http.NewRequest(method, b.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if b.token != "" {
+		req.Header.Set("Authorization", "Bearer "+b.token)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/octet-stream")
+	}
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取响应内容时出错: %v", err)
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrSyncNotFound
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("请求失败，状态码: %d", resp.StatusCode)
+	}
+	return data, nil
+}
+
+func (b *httpSyncBackend) Pull() ([]byte, error) {
+	return b.do(http.MethodGet, nil)
+}
+
+func (b *httpSyncBackend) Push(content []byte) error {
+	_, err := b.do(http.MethodPut, content)
+	return err
+}
@@ -0,0 +1,33 @@
+package tools
+
+import "testing"
+
+func TestNewGitSyncBackendPath(t *testing.T) {
+	tests := []struct {
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{"", defaultSyncFileName, false},
+		{"dora.json", "dora.json", false},
+		{"configs/./dora.json", "configs/dora.json", false},
+		{"configs/../dora.json", "dora.json", false},
+		{"/etc/passwd", "", true},
+		{"../outside.json", "", true},
+		{"configs/../../outside.json", "", true},
+		{"..", "", true},
+		{".", "", true},
+		{".git/config", "", true},
+		{`\\server\share\dora.json`, "", true},
+	}
+	for _, tt := range tests {
+		backend, err := newGitSyncBackend(SyncSettings{Repo: "git@example.com:dora.git", Path: tt.path})
+		if (err != nil) != tt.wantErr {
+			t.Errorf("sync.path为 %q 时错误为 %v，期望错误 %v", tt.path, err, tt.wantErr)
+			continue
+		}
+		if err == nil && backend.(*gitSyncBackend).path != tt.want {
+			t.Errorf("sync.path为 %q 时文件路径为 %q，期望 %q", tt.path, backend.(*gitSyncBackend).path, tt.want)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package tools
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// 命令模板中的参数
+// {{name}}：输入，默认值为上一次的输入
+// {{name=默认值}}：输入，没有历史输入时使用默认值
+// {{name:select(a,b,c)}}：从固定的选项中选择
+// {{name:choice(命令)}}：执行命令，按行作为选项选择，如 {{container:choice(docker ps --format {{.Names}})}}
+type TemplateParam struct {
+	Name    string
+	Default string
+	Kind    string
+	Arg     string
+	// 在命令中的原文，用于替换
+	Raw string
+}
+
+var templateParamNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+// 解析命令中的参数，同名参数只返回一次，{{.Names}} 这类不是以字母开头的不作为参数
+func ParseTemplateParams(command string) []TemplateParam {
+	params := []TemplateParam{}
+	seen := map[string]bool{}
+	for offset := 0; ; {
+		start := strings.Index(command[offset:], "{{")
+		if start == -1 {
+			break
+		}
+		start += offset
+		end := findTemplateEnd(command, start+2)
+		if end == -1 {
+			break
+		}
+		param, ok := parseTemplateParam(command[start+2 : end])
+		if !ok {
+			offset = start + 2
+			continue
+		}
+		param.Raw = command[start : end+2]
+		if !seen[param.Raw] {
+			seen[param.Raw] = true
+			params = append(params, param)
+		}
+		offset = end + 2
+	}
+	return params
+}
+
+// 查找参数结束的 }}，括号中的 }} 属于choice的命令，跳过
+func findTemplateEnd(command string, offset int) int {
+	depth := 0
+	for i := offset; i < len(command); i++ {
+		switch command[i] {
+		case '(':
+			depth++
+		case ')':
+			if depth > 0 {
+				depth--
+			}
+		case '}':
+			if depth == 0 && strings.HasPrefix(command[i:], "}}") {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func parseTemplateParam(body string) (TemplateParam, bool) {
+	param := TemplateParam{Kind: "input"}
+	if body == "" || !unicode.IsLetter(rune(body[0])) && body[0] != '_' {
+		return param, false
+	}
+	name := body
+	if i := strings.IndexAny(body, ":="); i != -1 {
+		name = body[:i]
+		rest := body[i+1:]
+		if body[i] == '=' {
+			param.Default = rest
+		} else {
+			open := strings.Index(rest, "(")
+			if open == -1 || !strings.HasSuffix(rest, ")") {
+				return param, false
+			}
+			param.Kind = rest[:open]
+			param.Arg = rest[open+1 : len(rest)-1]
+			if param.Kind != "select" && param.Kind != "choice" {
+				return param, false
+			}
+		}
+	}
+	if !templateParamNamePattern.MatchString(name) {
+		return param, false
+	}
+	param.Name = name
+	return param, true
+}
+
+// 不交互地替换参数：使用values中的值，其次是{{name=默认值}}的默认值，返回缺少值的参数
+func FillTemplate(command string, values map[string]string) (string, []string) {
+	missing := []string{}
+	for _, param := range ParseTemplateParams(command) {
+		value, ok := values[param.Name]
+		if !ok && param.Default != "" {
+			value, ok = param.Default, true
+		}
+		if !ok {
+			if !SliceContains(missing, param.Name) {
+				missing = append(missing, param.Name)
+			}
+			continue
+		}
+		command = strings.ReplaceAll(command, param.Raw, value)
+	}
+	return command, missing
+}
@@ -0,0 +1,122 @@
+package tools
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseTemplateParams(t *testing.T) {
+	tests := []struct {
+		name    string
+		command string
+		want    []TemplateParam
+	}{
+		{
+			name:    "没有参数",
+			command: "ls -la",
+			want:    []TemplateParam{},
+		},
+		{
+			name:    "输入和默认值",
+			command: "git checkout {{branch}} && echo {{msg=hello}}",
+			want: []TemplateParam{
+				{Name: "branch", Kind: "input", Raw: "{{branch}}"},
+				{Name: "msg", Default: "hello", Kind: "input", Raw: "{{msg=hello}}"},
+			},
+		},
+		{
+			name:    "固定选项",
+			command: "kubectl -n {{ns:select(dev,prod)}} get pods",
+			want: []TemplateParam{
+				{Name: "ns", Kind: "select", Arg: "dev,prod", Raw: "{{ns:select(dev,prod)}}"},
+			},
+		},
+		{
+			name:    "choice的命令中嵌套{{}}",
+			command: "docker logs -f {{container:choice(docker ps --format {{.Names}})}}",
+			want: []TemplateParam{
+				{Name: "container", Kind: "choice", Arg: "docker ps --format {{.Names}}", Raw: "{{container:choice(docker ps --format {{.Names}})}}"},
+			},
+		},
+		{
+			name:    "choice中嵌套多层括号",
+			command: "echo {{v:choice(echo $(date) {{.X}})}} {{w}}",
+			want: []TemplateParam{
+				{Name: "v", Kind: "choice", Arg: "echo $(date) {{.X}}", Raw: "{{v:choice(echo $(date) {{.X}})}}"},
+				{Name: "w", Kind: "input", Raw: "{{w}}"},
+			},
+		},
+		{
+			name:    "go模板不作为参数",
+			command: "docker ps --format '{{.Names}}' {{ .ID }}",
+			want:    []TemplateParam{},
+		},
+		{
+			name:    "同名参数只返回一次",
+			command: "cp {{file}} {{file}}.bak",
+			want: []TemplateParam{
+				{Name: "file", Kind: "input", Raw: "{{file}}"},
+			},
+		},
+		{
+			name:    "不支持的类型",
+			command: "echo {{x:pick(a,b)}}",
+			want:    []TemplateParam{},
+		},
+		{
+			name:    "没有结束的}}",
+			command: "echo {{name",
+			want:    []TemplateParam{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseTemplateParams(tt.command); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseTemplateParams(%q) = %+v，期望 %+v", tt.command, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindTemplateEnd(t *testing.T) {
+	tests := []struct {
+		command string
+		offset  int
+		want    int
+	}{
+		{"{{name}}", 2, 6},
+		{"{{c:choice(ls {{.X}})}} rest", 2, 21},
+		{"{{c:choice((a) {{b}})}}", 2, 21},
+		{"{{c:choice(ls }}", 2, -1},
+		{"{{a) }}", 2, 5},
+		{"{{name", 2, -1},
+	}
+	for _, tt := range tests {
+		if got := findTemplateEnd(tt.command, tt.offset); got != tt.want {
+			t.Errorf("findTemplateEnd(%q, %d) = %d，期望 %d", tt.command, tt.offset, got, tt.want)
+		}
+	}
+}
+
+func TestFillTemplate(t *testing.T) {
+	tests := []struct {
+		name    string
+		command string
+		values  map[string]string
+		want    string
+		missing []string
+	}{
+		{"使用传入的值", "git checkout {{branch}}", map[string]string{"branch": "main"}, "git checkout main", []string{}},
+		{"使用默认值", "echo {{msg=hi}}", nil, "echo hi", []string{}},
+		{"传入的值优先于默认值", "echo {{msg=hi}}", map[string]string{"msg": "yo"}, "echo yo", []string{}},
+		{"缺少参数", "cp {{src}} {{dst}}", map[string]string{"src": "a"}, "cp a {{dst}}", []string{"dst"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, missing := FillTemplate(tt.command, tt.values)
+			if got != tt.want || !reflect.DeepEqual(missing, tt.missing) {
+				t.Errorf("FillTemplate(%q) = %q, %v，期望 %q, %v", tt.command, got, missing, tt.want, tt.missing)
+			}
+		})
+	}
+}